@@ -29,3 +29,37 @@ func reluPrime(x float64) float64 {
 	}
 	return 1.
 }
+
+// Activation selects the transfer function of a layer
+type Activation int
+
+const (
+	Linear Activation = iota
+	Sigmoid
+	Tanh
+	ReLU
+)
+
+func (a Activation) f(x float64) float64 {
+	switch a {
+	case Sigmoid:
+		return sigmoid(x)
+	case Tanh:
+		return math.Tanh(x)
+	case ReLU:
+		return relu(x)
+	}
+	return x
+}
+
+func (a Activation) prime(x float64) float64 {
+	switch a {
+	case Sigmoid:
+		return sigmoidPrime(x)
+	case Tanh:
+		return tanhPrime(x)
+	case ReLU:
+		return reluPrime(x)
+	}
+	return 1.
+}
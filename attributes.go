@@ -0,0 +1,102 @@
+package goann
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Attributes is a table of static catchment attributes, one vector per basin (keyed by station ID)
+type Attributes struct {
+	Names  []string             // attribute names
+	Values map[string][]float64 // [station ID][attribute]; NaN: missing
+}
+
+// Get returns the attribute vector of a station
+func (a *Attributes) Get(id string) ([]float64, error) {
+	v, ok := a.Values[id]
+	if !ok {
+		return nil, fmt.Errorf("no static attributes found for station %s", id)
+	}
+	return v, nil
+}
+
+// ReadAttributes reads a comma-delimited attribute table: a header row of attribute names, one
+// row per basin with its station ID in the first column
+func ReadAttributes(fp string) (*Attributes, error) {
+	f, err := os.Open(fp)
+	if err != nil {
+		return nil, fmt.Errorf("ReadAttributes: %v", err)
+	}
+	defer f.Close()
+	a, err := parseAttributes(csv.NewReader(f), false)
+	if err != nil {
+		return nil, fmt.Errorf("ReadAttributes %s: %v", fp, err)
+	}
+	return a, nil
+}
+
+// parseAttributes reads an attribute table. When skipText, non-numeric (e.g., categorical)
+// columns are dropped, otherwise they are an error.
+func parseAttributes(r *csv.Reader, skipText bool) (*Attributes, error) {
+	recs, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(recs) < 1 {
+		return nil, fmt.Errorf("empty table")
+	}
+	nc := len(recs[0]) - 1
+	vals, keep := make([][]float64, len(recs)-1), make([]bool, nc)
+	for i := range keep {
+		keep[i] = true
+	}
+	for j, rec := range recs[1:] {
+		vals[j] = make([]float64, nc)
+		for i := range vals[j] {
+			if i+1 >= len(rec) {
+				return nil, fmt.Errorf("station %s: expecting %d values", rec[0], nc)
+			}
+			if vals[j][i], err = parseValue(rec[i+1]); err != nil {
+				if !skipText {
+					return nil, fmt.Errorf("station %s: %s: %v", rec[0], recs[0][i+1], err)
+				}
+				keep[i] = false
+			}
+		}
+	}
+
+	a := &Attributes{Values: make(map[string][]float64, len(vals))}
+	for i, k := range keep {
+		if k {
+			a.Names = append(a.Names, strings.TrimSpace(recs[0][i+1]))
+		}
+	}
+	for j, rec := range recs[1:] {
+		v := make([]float64, 0, len(a.Names))
+		for i, k := range keep {
+			if k {
+				v = append(v, vals[j][i])
+			}
+		}
+		a.Values[strings.TrimSpace(rec[0])] = v
+	}
+	return a, nil
+}
+
+// merge appends the attributes of b to those of a, for stations found in both
+func (a *Attributes) merge(b *Attributes) {
+	if a.Values == nil {
+		a.Names, a.Values = b.Names, b.Values
+		return
+	}
+	a.Names = append(a.Names, b.Names...)
+	for id, v := range a.Values {
+		if w, ok := b.Values[id]; ok {
+			a.Values[id] = append(v, w...)
+		} else {
+			delete(a.Values, id)
+		}
+	}
+}
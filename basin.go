@@ -0,0 +1,171 @@
+package goann
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Basin pairs a basin's time series with its static catchment attributes (e.g., area, slope,
+// land cover, soils), the dataset format used for regional modelling across many basins
+type Basin struct {
+	ID     string
+	Static []float64   // static catchment attributes
+	Input  [][]float64 // dynamic forcings [timestep][forcing]
+	Target [][]float64 // [timestep][target]
+	Flag   []Flag      // [timestep] quality flag of the target (nil: none)
+	Weight []float64   // [timestep] loss weight (nil: 1), e.g., set by a FlagPolicy
+}
+
+// Sampling selects how training windows are distributed across basins
+type Sampling int
+
+const (
+	Balanced     Sampling = iota // every basin is equally likely to be sampled
+	Proportional                 // basins are sampled in proportion to their record length
+)
+
+// staticSetter is implemented by networks that take static attributes separately (EALSTM)
+type staticSetter interface{ SetStatic(static []float64) error }
+
+// check returns an error when the target, flag or weight records do not match the forcings in length
+func (b *Basin) check() error {
+	nt := len(b.Input)
+	switch {
+	case len(b.Target) != nt:
+		return fmt.Errorf("basin %s: input (%d) and target (%d) lengths differ", b.ID, nt, len(b.Target))
+	case b.Flag != nil && len(b.Flag) != nt:
+		return fmt.Errorf("basin %s: input (%d) and flag (%d) lengths differ", b.ID, nt, len(b.Flag))
+	case b.Weight != nil && len(b.Weight) != nt:
+		return fmt.Errorf("basin %s: input (%d) and weight (%d) lengths differ", b.ID, nt, len(b.Weight))
+	}
+	return nil
+}
+
+// withStatic returns the basin's forcings, with its static attributes appended to every timestep
+// (unless the network takes them separately)
+func (b *Basin) withStatic(r Recurrent) ([][]float64, error) {
+	if ss, ok := r.(staticSetter); ok {
+		if err := ss.SetStatic(b.Static); err != nil {
+			return nil, fmt.Errorf("basin %s: %v", b.ID, err)
+		}
+		return b.Input, nil
+	}
+	if len(b.Static) == 0 {
+		return b.Input, nil
+	}
+	o := make([][]float64, len(b.Input))
+	for t, x := range b.Input {
+		o[t] = make([]float64, 0, len(x)+len(b.Static))
+		o[t] = append(append(o[t], x...), b.Static...)
+	}
+	return o, nil
+}
+
+// TrainBasins trains a single (regional) network on nbatch mini-batches of lookback windows sampled
+// across a collection of basins. Networks other than EALSTM receive the static attributes appended
+// to the forcings of every timestep. Returns the mean loss (Lookback.Loss) of the final batch.
+func TrainBasins(r Recurrent, basins []Basin, lb Lookback, sampling Sampling, nbatch int, lr float64) (float64, error) {
+	if len(basins) == 0 {
+		return 0., fmt.Errorf("TrainBasins: no basins given")
+	}
+	cum, tot := make([]float64, len(basins)), 0. // cumulative sampling weights
+	for i, b := range basins {
+		if err := b.check(); err != nil {
+			return 0., fmt.Errorf("TrainBasins: %v", err)
+		}
+		var err error
+		if lb, err = lb.check(len(b.Input)); err != nil {
+			return 0., fmt.Errorf("TrainBasins: basin %s: %v", b.ID, err)
+		}
+		if sampling == Proportional {
+			tot += float64(len(b.Input) - lb.Lookback + 1) // number of available windows
+		} else {
+			tot++
+		}
+		cum[i] = tot
+	}
+	for i := range cum {
+		cum[i] /= tot
+	}
+
+	xs := make([][][]float64, len(basins)) // network inputs, built once per basin
+	for i := range basins {
+		var err error
+		if xs[i], err = basins[i].withStatic(r); err != nil {
+			return 0., fmt.Errorf("TrainBasins: %v", err)
+		}
+	}
+	ss, separate := r.(staticSetter)
+
+	scl, loss := 1./float64(lb.BatchSize*lb.Targets), 0.
+	for b := 0; b < nbatch; b++ {
+		loss = 0.
+		for n := 0; n < lb.BatchSize; n++ {
+			u, i := rand.Float64(), 0
+			for cum[i] < u && i < len(cum)-1 {
+				i++
+			}
+			bsn := &basins[i]
+			if separate {
+				ss.SetStatic(bsn.Static) // checked by withStatic
+			}
+			loss += lb.window(r, xs[i], bsn.Target, bsn.Weight, lb.sample(len(bsn.Input)), scl)
+		}
+		ApplyDiff(r.Params(), lr)
+	}
+	return loss * scl, nil
+}
+
+// BasinScore performance of a (regional) network at a single basin, for every target
+type BasinScore struct {
+	ID                          string
+	NSE, RMSE                   []float64 // all observations, weighted by Basin.Weight
+	NSEflagged, RMSEflagged     []float64 // flagged observations only (unweighted; NaN when there are none)
+	NSEunflagged, RMSEunflagged []float64 // unflagged observations only (unweighted)
+}
+
+// EvaluateBasins simulates every basin from rest and scores the predictions against the targets.
+// Scores are also reported separately for flagged and unflagged periods.
+func EvaluateBasins(r Recurrent, basins []Basin) ([]BasinScore, error) {
+	for i := range basins {
+		if err := basins[i].check(); err != nil {
+			return nil, fmt.Errorf("EvaluateBasins: %v", err)
+		}
+	}
+	o := make([]BasinScore, len(basins))
+	for i := range basins {
+		b := &basins[i]
+		xs, err := b.withStatic(r)
+		if err != nil {
+			return nil, fmt.Errorf("EvaluateBasins: %v", err)
+		}
+		r.Reset()
+		ypred := make([][]float64, len(b.Input))
+		for t, x := range xs {
+			ypred[t] = r.Step(x)
+		}
+		o[i] = BasinScore{ID: b.ID}
+		if len(b.Target) == 0 {
+			continue
+		}
+		for k := range b.Target[0] {
+			nt := len(b.Target)
+			obs, sim, fo, uo := make([]float64, nt), make([]float64, nt), make([]float64, nt), make([]float64, nt)
+			for t := range b.Target {
+				obs[t], sim[t] = b.Target[t][k], ypred[t][k]
+				fo[t], uo[t] = math.NaN(), obs[t] // flagged and unflagged observations
+				if b.Flag != nil && b.Flag[t] != NoFlag {
+					fo[t], uo[t] = uo[t], fo[t]
+				}
+			}
+			o[i].NSE = append(o[i].NSE, nse(obs, sim, b.Weight))
+			o[i].RMSE = append(o[i].RMSE, rmse(obs, sim, b.Weight))
+			o[i].NSEflagged = append(o[i].NSEflagged, nse(fo, sim, nil))
+			o[i].RMSEflagged = append(o[i].RMSEflagged, rmse(fo, sim, nil))
+			o[i].NSEunflagged = append(o[i].NSEunflagged, nse(uo, sim, nil))
+			o[i].RMSEunflagged = append(o[i].RMSEunflagged, rmse(uo, sim, nil))
+		}
+	}
+	return o, nil
+}
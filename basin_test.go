@@ -0,0 +1,62 @@
+package goann
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTrainBasins(t *testing.T) {
+	xs, ys := seq(30, 2, 1., 0.), seq(30, 1, .5, 1.)
+	basins := []Basin{
+		{ID: "a", Input: xs, Target: ys, Static: []float64{.2}},
+		{ID: "b", Input: xs[:20], Target: ys[:20], Static: []float64{-.4}, Flag: make([]Flag, 20)},
+	}
+	if err := (FlagPolicy{IceConditions: 0.}).Apply(&basins[1]); err != nil {
+		t.Fatal(err)
+	}
+	lb := Lookback{Lookback: 5, Targets: 1, BatchSize: 4}
+	ls := NewLSTM(3, []int{4}, 1, Linear, .1) // 2 forcings and 1 static attribute
+	ea := NewEALSTM(2, 1, 4, 1, Linear, .1)
+	for _, r := range []Recurrent{&ls, &ea} {
+		if _, err := TrainBasins(r, basins, lb, Proportional, 3, .01); err != nil {
+			t.Fatalf("%T: %v", r, err)
+		}
+		s, err := EvaluateBasins(r, basins)
+		if err != nil {
+			t.Fatalf("%T: %v", r, err)
+		}
+		if len(s) != 2 || s[1].ID != "b" || math.IsNaN(s[1].NSE[0]) || !math.IsNaN(s[1].NSEflagged[0]) {
+			t.Errorf("%T: scores %+v", r, s)
+		}
+	}
+}
+
+func TestBasinValidation(t *testing.T) {
+	xs := seq(10, 2, 1., 0.)
+	lb := Lookback{Lookback: 3, Targets: 1, BatchSize: 1}
+	ea := NewEALSTM(2, 1, 3, 2, Linear, .1)
+	for name, b := range map[string]Basin{
+		"target": {ID: "x", Input: xs, Target: xs[:9], Static: []float64{1.}},
+		"weight": {ID: "x", Input: xs, Target: xs, Static: []float64{1.}, Weight: []float64{1.}},
+		"flag":   {ID: "x", Input: xs, Target: xs, Static: []float64{1.}, Flag: []Flag{NoFlag}},
+		"static": {ID: "x", Input: xs, Target: xs, Static: []float64{1., 2.}},
+	} {
+		good := Basin{ID: "g", Input: xs, Target: xs, Static: []float64{1.}}
+		if _, err := TrainBasins(&ea, []Basin{good, b}, lb, Balanced, 1, .01); err == nil {
+			t.Errorf("%s: TrainBasins accepted a mismatched basin", name)
+		}
+		if _, err := EvaluateBasins(&ea, []Basin{good, b}); err == nil {
+			t.Errorf("%s: EvaluateBasins accepted a mismatched basin", name)
+		}
+		if _, err := ea.Train(b); err == nil {
+			t.Errorf("%s: EALSTM.Train accepted a mismatched basin", name)
+		}
+	}
+	bad := Basin{ID: "x", Input: xs, Target: xs, Static: nil}
+	if _, err := ea.Predict(bad); err == nil {
+		t.Error("Predict accepted missing static attributes")
+	}
+	if _, err := ea.PredictMC(bad, 2); err == nil {
+		t.Error("PredictMC accepted missing static attributes")
+	}
+}
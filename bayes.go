@@ -0,0 +1,123 @@
+package goann
+
+import (
+	"math"
+	"math/rand"
+)
+
+// BayesNetwork is a Bayes-by-backprop (Blundell et.al., 2015) variant of Network, where every weight
+// holds a Gaussian variational posterior (mean and log-variance) rather than a single value. Weights
+// are sampled using the reparameterization trick, w = mu + exp(lv/2)*eps, eps ~ N(0,1), and learned
+// by minimizing the ELBO loss: the data negative log-likelihood plus the KL divergence of the
+// posterior from a N(0,prior) prior. Biases remain point estimates.
+type BayesNetwork struct {
+	net   Network
+	prior float64 // prior variance of the weights
+	klw   float64 // weight of the KL term, per training sample
+}
+
+// NewBayesNet m: number of input nodes; n: nodes per hidden layer; p: number of output nodes; nhl: number
+// of hidden layers; eta learning rate; prior: prior weight variance (~1.); ntrain: number of training
+// samples, over which the KL term is spread (taken as 1 when ntrain < 1). Outputs are Linear.
+func NewBayesNet(m, n, p, nhl int, eta, prior float64, ntrain int) BayesNetwork {
+	if ntrain < 1 {
+		ntrain = 1
+	}
+	bn := BayesNetwork{net: NewNet(m, n, p, nhl, eta), prior: prior, klw: 1. / float64(ntrain)}
+	bn.net.out = Linear
+	for _, w := range bn.weights() {
+		w.mu, w.lv = w.w, -10. // posteriors start narrow, centred on the usual initial weights
+	}
+	return bn
+}
+
+// SetOutput sets the activation of the output nodes (default Linear)
+func (bn *BayesNetwork) SetOutput(act Activation) { bn.net.out = act }
+
+func (bn *BayesNetwork) weights() []*weight {
+	var o []*weight
+	for _, n := range bn.net.nd {
+		o = append(o, n.b...)
+	}
+	return o
+}
+
+// sample draws a set of weights from the posterior
+func (bn *BayesNetwork) sample() {
+	for _, w := range bn.weights() {
+		w.eps = rand.NormFloat64()
+		w.w = w.mu + math.Exp(.5*w.lv)*w.eps
+	}
+}
+
+// KL divergence of the weight posteriors from the prior
+func (bn *BayesNetwork) KL() float64 {
+	s := 0.
+	for _, w := range bn.weights() {
+		s += .5 * (math.Log(bn.prior) - w.lv + (math.Exp(w.lv)+w.mu*w.mu)/bn.prior - 1.)
+	}
+	return s
+}
+
+// Train a single sample against a loss layer (e.g., MSE, or a Distribution), using a single weight
+// sample. Returns the sample's ELBO loss: the data loss plus its share of the KL term.
+func (bn *BayesNetwork) Train(input, trainer []float64, loss Loss) float64 {
+	return bn.TrainWeighted(input, trainer, loss, 1.)
+}
+
+// TrainWeighted as Train, where the sample's data loss (not its share of the KL term) is scaled by wt
+func (bn *BayesNetwork) TrainWeighted(input, trainer []float64, loss Loss, wt float64) float64 {
+	bn.sample()
+	y := bn.net.forward(input, false)
+	dy := loss.Diff(y, trainer)
+	for k := range dy {
+		dy[k] *= wt
+	}
+	bn.net.backward(dy)
+
+	eta := bn.net.eta
+	for _, w := range bn.weights() {
+		dmu := w.d + bn.klw*w.mu/bn.prior
+		dlv := w.d*w.eps*.5*math.Exp(.5*w.lv) + bn.klw*.5*(math.Exp(w.lv)/bn.prior-1.)
+		w.mu -= eta * dmu
+		w.lv -= eta * dlv
+		w.d = 0.
+	}
+	for _, n := range bn.net.nd {
+		n.bias -= eta * n.bd
+		n.bd = 0.
+	}
+	return wt*loss.Loss(y, trainer) + bn.klw*bn.KL()
+}
+
+// Predict feeds the input through a single random draw of the weights
+func (bn *BayesNetwork) Predict(input []float64) []float64 {
+	bn.sample()
+	return bn.net.forward(input, false)
+}
+
+// PredictMean feeds the input using the posterior mean of every weight
+func (bn *BayesNetwork) PredictMean(input []float64) []float64 {
+	for _, w := range bn.weights() {
+		w.w = w.mu
+	}
+	return bn.net.forward(input, false)
+}
+
+// PredictSamples feeds every input vector through nSamples draws of the weights (the same draw is
+// shared by every input), returning the sample mean, variance and quantiles at probabilities ps of
+// each output (empty when nSamples < 1)
+func (bn *BayesNetwork) PredictSamples(input [][]float64, nSamples int, ps ...float64) MCPrediction {
+	if nSamples < 1 {
+		return mcSummary(nil, ps)
+	}
+	samples := make([][][]float64, nSamples)
+	for s := range samples {
+		bn.sample()
+		samples[s] = make([][]float64, len(input))
+		for t, x := range input {
+			samples[s][t] = bn.net.forward(x, false)
+		}
+	}
+	return mcSummary(samples, ps)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"math/rand"
 	"time"
 
@@ -37,8 +38,15 @@ func mnistTrain(net *Network) {
 	rand.Seed(time.Now().UTC().UnixNano())
 	t1 := time.Now()
 
-	ls := mnist.GetLbl("../dat/train-labels-idx1-ubyte.gz", 60000, false)   // .gz files at: http://yann.lecun.com/exdb/mnist/
-	imgs := mnist.GetImg("../dat/train-images-idx3-ubyte.gz", 60000, false) // .gz files at: http://yann.lecun.com/exdb/mnist/
+	ls, err := mnist.GetLbl("../dat/train-labels-idx1-ubyte.gz", 60000) // .gz files at: http://yann.lecun.com/exdb/mnist/
+	if err != nil {
+		log.Fatalln(err)
+	}
+	imgs, _, _, err := mnist.GetImg("../dat/train-images-idx3-ubyte.gz", 60000) // .gz files at: http://yann.lecun.com/exdb/mnist/
+	if err != nil {
+		log.Fatalln(err)
+	}
+	mnist.Invert(imgs)
 
 	for epochs := 0; epochs < 5; epochs++ {
 		for j, a := range imgs {
@@ -65,8 +73,15 @@ func mnistPredict(net *Network) {
 	fmt.Println("Predicting..")
 	t1 := time.Now()
 
-	ls := mnist.GetLbl("../dat/t10k-labels-idx1-ubyte.gz", 10000, false)   // .gz files at: http://yann.lecun.com/exdb/mnist/
-	imgs := mnist.GetImg("../dat/t10k-images-idx3-ubyte.gz", 10000, false) // .gz files at: http://yann.lecun.com/exdb/mnist/
+	ls, err := mnist.GetLbl("../dat/t10k-labels-idx1-ubyte.gz", 10000) // .gz files at: http://yann.lecun.com/exdb/mnist/
+	if err != nil {
+		log.Fatalln(err)
+	}
+	imgs, _, _, err := mnist.GetImg("../dat/t10k-images-idx3-ubyte.gz", 10000) // .gz files at: http://yann.lecun.com/exdb/mnist/
+	if err != nil {
+		log.Fatalln(err)
+	}
+	mnist.Invert(imgs)
 
 	score := 0
 	for j, a := range imgs {
@@ -1,96 +1,55 @@
 package mnist
 
 import (
-	"bytes"
-	"compress/gzip"
 	"fmt"
-	"image"
-	"image/png"
-	"log"
-	"os"
+
+	goann "github.com/maseology/goANN"
 )
 
 // functions to aquire training and test images from: http://yann.lecun.com/exdb/mnist/
-func GetImg(gzfp string, n int, prnt bool) [][]byte {
+// (or any dataset of the same IDX format, e.g., Fashion-MNIST, EMNIST)
 
-	fmt.Printf("openning %s..\n", gzfp)
-	b, err := os.ReadFile(gzfp)
+// GetImg returns the first n images (all when n <= 0) of an IDX image file (gzipped or not), as
+// [image][row*column] pixels, along with the image rows and columns
+func GetImg(fp string, n int) ([][]byte, int, int, error) {
+	t, err := goann.ReadIDX(fp)
 	if err != nil {
-		log.Fatalf(" getImg fail-1: %v\n", err)
+		return nil, 0, 0, err
 	}
-
-	// buf := bytes.NewReader(b) // if file already decompressed, OR:
-
-	var buf bytes.Buffer
-	gzbuf := bytes.NewBuffer(b)
-	r, err := gzip.NewReader(gzbuf)
-	if err != nil {
-		log.Fatalf(" getImg fail-2: %v\n", err)
+	if t.Type != goann.IDXUint8 || len(t.Dims) != 3 {
+		return nil, 0, 0, fmt.Errorf("GetImg %s: expecting [images][rows][columns] uint8, found %v %s", fp, t.Dims, t.Type)
 	}
-	_, err = buf.ReadFrom(r)
-	if err != nil {
-		log.Fatalf(" getImg fail-3: %v\n", err)
+	if n <= 0 || n > t.Dims[0] {
+		n = t.Dims[0]
 	}
-
-	// skip header
-	head := make([]byte, 16)
-	buf.Read(head)
-
+	nr, nc := t.Dims[1], t.Dims[2]
 	o := make([][]byte, n)
-	for i := 0; i < n; i++ {
-		pixels := make([]byte, 28*28)
-		for j := 0; j < 28*28; j++ {
-			pixels[j], _ = buf.ReadByte()
-			pixels[j] = 255 - pixels[j]
-		}
-		o[i] = pixels
-
-		if prnt {
-			img := image.NewGray(image.Rect(0, 0, 28, 28))
-			copy(img.Pix, pixels)
-
-			out, err := os.Create(fmt.Sprintf("img%d.png", i))
-			if err != nil {
-				log.Fatalf(" getImg print fail-4: %v\n", err)
-			}
-			if err := png.Encode(out, img); err != nil {
-				log.Fatalf(" getImg print fail-5 3: %v\n", err)
-			}
-		}
+	for i := range o {
+		o[i] = t.U8[i*nr*nc : (i+1)*nr*nc]
 	}
-	return o
+	return o, nr, nc, nil
 }
 
-func GetLbl(gzfp string, n int, prnt bool) []byte {
-
-	fmt.Printf("openning %s..\n", gzfp)
-	b, err := os.ReadFile(gzfp) // .gz files at: http://yann.lecun.com/exdb/mnist/
+// GetLbl returns the first n labels (all when n <= 0) of an IDX label file
+func GetLbl(fp string, n int) ([]byte, error) {
+	t, err := goann.ReadIDX(fp)
 	if err != nil {
-		log.Fatalf(" getLbl fail-1: %v\n", err)
+		return nil, err
 	}
-
-	// buf := bytes.NewReader(b) // if file already decompressed, OR:
-
-	var buf bytes.Buffer
-	gzbuf := bytes.NewBuffer(b)
-	r, err := gzip.NewReader(gzbuf)
-	if err != nil {
-		log.Fatalf(" getImg fail-2: %v\n", err)
+	if t.Type != goann.IDXUint8 || len(t.Dims) != 1 {
+		return nil, fmt.Errorf("GetLbl %s: expecting [labels] uint8, found %v %s", fp, t.Dims, t.Type)
 	}
-	_, err = buf.ReadFrom(r)
-	if err != nil {
-		log.Fatalf(" getImg fail-3: %v\n", err)
+	if n <= 0 || n > t.Dims[0] {
+		n = t.Dims[0]
 	}
+	return t.U8[:n], nil
+}
 
-	// skip header
-	head := make([]byte, 8)
-	buf.Read(head)
-
-	o := make([]byte, n)
-	for i := 0; i < n; i++ {
-		if o[i], err = buf.ReadByte(); err != nil {
-			log.Fatalf(" getLbl fail-4: %v\n", err)
+// Invert sets every pixel p to 255-p (dark characters on a light background)
+func Invert(imgs [][]byte) {
+	for _, img := range imgs {
+		for j, p := range img {
+			img[j] = 255 - p
 		}
 	}
-	return o
 }
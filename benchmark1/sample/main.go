@@ -12,10 +12,14 @@ import (
 
 func main() {
 
-	imgs := mnist.GetImg("../dat/t10k-images-idx3-ubyte.gz", 10000, false) // .gz files at: http://yann.lecun.com/exdb/mnist/
+	imgs, nr, nc, err := mnist.GetImg("../dat/t10k-images-idx3-ubyte.gz", 10000) // .gz files at: http://yann.lecun.com/exdb/mnist/
+	if err != nil {
+		log.Fatalln(err)
+	}
+	mnist.Invert(imgs)
 
 	for i := 0; i < 10; i++ {
-		img := image.NewGray(image.Rect(0, 0, 28, 28))
+		img := image.NewGray(image.Rect(0, 0, nc, nr))
 		copy(img.Pix, imgs[i])
 
 		out, err := os.Create(fmt.Sprintf("img%02d.png", i))
@@ -0,0 +1,65 @@
+package dset
+
+import (
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	goann "github.com/maseology/goANN"
+)
+
+// Forcings returns the meteorological inputs: rainfall, snowfall, snowmelt, Tx, Tn, Pa
+func (d *dset) Forcings() []float64 { return []float64{d.rf, d.sf, d.sm, d.tx, d.tn, d.pa} }
+
+// ReadBasins loads every OWRC-format CSV in dir (the station ID taken from the file name) and pairs
+// it with its row of the static attributes table attfp (header row of attribute names, station ID
+// in the first column; may lie in dir; "": no static attributes). Returns the basins (forcings as input, runoff as target), their timestamps
+// and the attribute names. Missing runoff is left NaN (excluded from training); days having missing
+// forcings are imputed and excluded from training (goann.SkipNaN).
+func ReadBasins(dir, attfp string) ([]goann.Basin, [][]time.Time, []string) {
+	atts := &goann.Attributes{}
+	if attfp != "" {
+		var err error
+		if atts, err = goann.ReadAttributes(attfp); err != nil {
+			log.Fatalf("ReadBasins failed: %v\n", err)
+		}
+	}
+
+	fps, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	if err != nil {
+		log.Fatalf("ReadBasins failed: %v\n", err)
+	}
+	bs, ts := make([]goann.Basin, 0, len(fps)), make([][]time.Time, 0, len(fps))
+	for _, fp := range fps {
+		if attfp != "" && filepath.Clean(fp) == filepath.Clean(attfp) {
+			continue
+		}
+		id := strings.TrimSuffix(filepath.Base(fp), filepath.Ext(fp))
+		var s []float64
+		if attfp != "" {
+			if s, err = atts.Get(id); err != nil {
+				log.Fatalf("ReadBasins: %v\n", err)
+			}
+		}
+		t, err := goann.ReadOWRC(fp)
+		if err != nil {
+			log.Fatalf("ReadBasins failed: %v\n", err)
+		}
+		x, err := t.Rows("Rf", "Sf", "Sm", "Tx", "Tn", "Pa")
+		if err != nil {
+			log.Fatalf("ReadBasins: %s: %v\n", fp, err)
+		}
+		y, err := t.Rows("Flow")
+		if err != nil {
+			log.Fatalf("ReadBasins: %s: %v\n", fp, err)
+		}
+		b := goann.Basin{ID: id, Static: s, Flag: t.Flag}
+		if b.Input, b.Target, err = goann.FillNaN(x, y, goann.SkipNaN); err != nil {
+			log.Fatalf("ReadBasins: %s: %v\n", fp, err)
+		}
+		bs = append(bs, b)
+		ts = append(ts, t.Time)
+	}
+	return bs, ts, atts.Names
+}
@@ -54,3 +54,45 @@ func ToPng(fp string, o, s []float64) {
 		log.Fatalf(" obsSim error: %v", err)
 	}
 }
+
+// ToPngBand plots the observed (o) and simulated (s) hydrographs, along with the bounds (lo, hi) of
+// a predictive interval (e.g., percentiles of goann.MCPrediction)
+func ToPngBand(fp string, o, s, lo, hi []float64) {
+	sequentialLine := func(v []float64) plotter.XYs {
+		pts, c := make(plotter.XYs, len(v)), 0
+		for i := range pts {
+			if math.IsNaN(v[i]) {
+				continue
+			}
+			pts[c].X = float64(i)
+			pts[c].Y = v[i]
+			c++
+		}
+		return pts[:c]
+	}
+
+	p := plot.New()
+	p.X.Label.Text = ""
+	p.Y.Label.Text = "discharge"
+
+	newLine := func(v []float64, c color.Color) *plotter.Line {
+		l, err := plotter.NewLine(sequentialLine(v))
+		if err != nil {
+			log.Fatalf(" obsSim error: %v", err)
+		}
+		l.Color = c
+		return l
+	}
+	pl, ph := newLine(lo, color.RGBA{R: 255, G: 180, B: 180, A: 255}), newLine(hi, color.RGBA{R: 255, G: 180, B: 180, A: 255})
+	ps, po := newLine(s, color.RGBA{R: 255, A: 255}), newLine(o, color.RGBA{B: 255, A: 255})
+
+	p.Add(pl, ph, ps, po)
+	p.Legend.Add("obs", po)
+	p.Legend.Add("sim", ps)
+	p.Legend.Add("bounds", pl)
+	p.Legend.Top = true
+
+	if err := p.Save(24*vg.Inch, 8*vg.Inch, fp); err != nil {
+		log.Fatalf(" obsSim error: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/maseology/goANN/benchmark2/dset"
+	"github.com/maseology/goANN/benchmark2/output"
+
+	goann "github.com/maseology/goANN"
+	"github.com/maseology/goHydro/pet"
+	"github.com/maseology/objfunc"
+)
+
+// Test 2 repeated using a Jordan network: previous predictions are carried by the
+// network's context nodes, rather than being fed back as inputs
+func main() {
+	fmt.Println("Training..")
+	t1 := time.Now()
+
+	nhn := 3
+	tlag := 3
+
+	net := goann.NewJordan(tlag+1, nhn, 1, 0.1)
+	net.TruncateBPTT(30)
+	owrcTrain(&net, "../02EC018.csv", tlag)
+
+	elapsed := time.Since(t1)
+	fmt.Printf("Time taken to train: %s\n nhn: %d  tlag: %d", elapsed, nhn, tlag)
+}
+
+func owrcTrain(net *goann.SRN, fp string, tlag int) {
+	rand.Seed(time.Now().UTC().UnixNano())
+
+	ts, dat := dset.ReadOWRC(fp)
+	ts = ts[tlag:]
+
+	input, qTrain := func() (o, q [][]float64) {
+		// collect
+		yield, qq := make([]float64, len(dat)), make([]float64, len(dat))
+		for i, v := range dat {
+			yield[i] = v.Yeild()
+			qq[i] = v.Runoff()
+		}
+
+		// re-scale
+		yield = objfunc.RescaleLim(yield, .1, .85)
+		qq = objfunc.RescaleLim(qq, .1, .85)
+
+		o, q = make([][]float64, len(ts)), make([][]float64, len(ts))
+		for i, t := range ts {
+			o[i] = make([]float64, tlag+1)
+			o[i][tlag] = pet.SineCurve(t)
+			for ii := 0; ii < tlag; ii++ {
+				o[i][ii] = yield[i+tlag-ii]
+			}
+			q[i] = []float64{qq[i+tlag]}
+		}
+		return
+	}()
+
+	for epochs := 0; epochs < 2.5e7/len(ts); epochs++ {
+		net.Train(input, qTrain)
+	}
+
+	func() { // print
+		obs, sim := make([]float64, len(ts)), make([]float64, len(ts))
+		for i, y := range net.Predict(input) {
+			sim[i] = (y[0] - .1) / (.85 - .1)
+			obs[i] = (qTrain[i][0] - .1) / (.85 - .1)
+		}
+		fmt.Println(objfunc.NSE(obs, sim))
+		output.ToPng("hyd.png", obs, sim)
+		output.ToCsv("hyd.csv", ts, obs, sim)
+	}()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/maseology/goANN/benchmark2/dset"
+
+	goann "github.com/maseology/goANN"
+)
+
+// a single regional entity-aware LSTM trained across a collection of OWRC station records
+// (see README, Test 3, for preparing the stations directory), e.g.:
+//
+//	go run . -stations ../stations -attributes ../stations/attributes.csv
+//	go run . -stations ../../benchmark2 -attributes "" // the stations included with the repository, no attributes
+func main() {
+	dir := flag.String("stations", "../stations", "directory of OWRC station CSVs, one per station named <station ID>.csv")
+	attfp := flag.String("attributes", "../stations/attributes.csv", "static attributes CSV (\"\": none)")
+	flag.Parse()
+	if _, err := os.Stat(*dir); err != nil {
+		log.Fatalf("stations directory not found: %v (see README, Test 3, to prepare it)", err)
+	}
+
+	rand.Seed(time.Now().UTC().UnixNano())
+	fmt.Println("Training..")
+	t1 := time.Now()
+
+	basins, _, atts := dset.ReadBasins(*dir, *attfp)
+	fmt.Printf(" %d basins, %d static attributes\n", len(basins), len(atts))
+	rescale(basins)
+	for i := range basins {
+		if err := (goann.FlagPolicy{goann.IceConditions: 0., goann.Estimate: .5}).Apply(&basins[i]); err != nil { // ice-affected flows are unreliable
+			log.Fatalf("%v", err)
+		}
+	}
+
+	net := goann.NewEALSTM(len(basins[0].Input[0]), len(atts), 64, 1, goann.Linear, 0.01)
+	lb := goann.Lookback{Lookback: 365, Targets: 1, BatchSize: 64}
+	for epoch := 0; epoch < 30; epoch++ {
+		loss, err := goann.TrainBasins(&net, basins, lb, goann.Proportional, 100, 0.01)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		fmt.Printf(" epoch %d: loss %.4f\n", epoch, loss)
+	}
+	fmt.Printf("Time taken to train: %s\n", time.Since(t1))
+
+	fmt.Println("station\tNSE\tRMSE\tNSE(flagged)\tNSE(unflagged)")
+	scores, err := goann.EvaluateBasins(&net, basins)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	for _, s := range scores {
+		fmt.Printf("%s\t%.3f\t%.3f\t%.3f\t%.3f\n", s.ID, s.NSE[0], s.RMSE[0], s.NSEflagged[0], s.NSEunflagged[0])
+	}
+}
+
+// rescale standardizes forcings, targets and static attributes across all basins
+func rescale(basins []goann.Basin) {
+	standardize := func(get func(b *goann.Basin) [][]float64) {
+		mu, sd, n := []float64{}, []float64{}, []float64{}
+		for i := range basins {
+			for _, v := range get(&basins[i]) {
+				if len(mu) == 0 {
+					mu, sd, n = make([]float64, len(v)), make([]float64, len(v)), make([]float64, len(v))
+				}
+				for k, x := range v {
+					if math.IsNaN(x) { // missing
+						continue
+					}
+					mu[k] += x
+					sd[k] += x * x
+					n[k]++
+				}
+			}
+		}
+		for k := range mu {
+			mu[k] /= n[k]
+			sd[k] = sd[k]/n[k] - mu[k]*mu[k]
+			if sd[k] > 0. {
+				sd[k] = 1. / math.Sqrt(sd[k])
+			} else {
+				sd[k] = 1.
+			}
+		}
+		for i := range basins {
+			for _, v := range get(&basins[i]) {
+				for k := range v {
+					v[k] = (v[k] - mu[k]) * sd[k]
+				}
+			}
+		}
+	}
+	standardize(func(b *goann.Basin) [][]float64 { return b.Input })
+	standardize(func(b *goann.Basin) [][]float64 { return b.Target })
+	standardize(func(b *goann.Basin) [][]float64 { return [][]float64{b.Static} })
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	goann "github.com/maseology/goANN"
+)
+
+// times a full training iteration (forward, backward and parameter update) of the
+// matrix LSTM over a year-long sequence of daily forcings, for increasing memory cells
+func main() {
+	const (
+		xdim  = 5   // forcings: rain, snowmelt, Tx, Tn, Pa
+		nseq  = 365 // days
+		niter = 5
+	)
+	rand.Seed(1)
+
+	xList, yList := make([][]float64, nseq), make([][]float64, nseq)
+	for t := 0; t < nseq; t++ {
+		xList[t] = make([]float64, xdim)
+		for i := range xList[t] {
+			xList[t][i] = rand.Float64()
+		}
+		yList[t] = []float64{rand.Float64()}
+	}
+
+	fmt.Println("mem_cell_ct\ttime/iteration")
+	for _, mc := range []int{10, 25, 50, 100} {
+		lw := goann.NewLSTMnetwork(goann.NewLSTMparam(mc, xdim), 1, goann.Linear)
+		t1 := time.Now()
+		for iter := 0; iter < niter; iter++ {
+			lw.XlistClear()
+			for _, x := range xList {
+				lw.XlistAdd(x)
+			}
+			lw.YListIs(yList, goann.MSE{})
+			lw.ApplyDiff(.1)
+		}
+		fmt.Printf("%d\t\t%v\n", mc, time.Since(t1)/niter)
+	}
+}
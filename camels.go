@@ -0,0 +1,215 @@
+package goann
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CAMELS (Addor et.al., 2017; Newman et.al., 2015) dataset layout:
+//  basin_mean_forcing/<source>/<huc>/<gauge>_lump_*_forcing_leap.txt
+//  usgs_streamflow/<huc>/<gauge>_streamflow_qc.txt
+//  camels_<clim|geol|hydro|soil|topo|vege>.txt (semicolon-delimited attribute tables)
+
+// ReadCAMELSBasin locates and reads the forcing (source: "daymet", "maurer" or "nldas") and
+// streamflow files of a gauge under the CAMELS root directory (see ReadCAMELS)
+func ReadCAMELSBasin(root, source, gauge string) (*Table, error) {
+	find := func(pattern string) (string, error) {
+		fps, err := filepath.Glob(pattern)
+		if err != nil {
+			return "", err
+		}
+		if len(fps) == 0 {
+			return "", fmt.Errorf("ReadCAMELSBasin: no file matching %s", pattern)
+		}
+		return fps[0], nil
+	}
+	ffp, err := find(filepath.Join(root, "basin_mean_forcing", source, "*", gauge+"_lump_*forcing_leap.txt"))
+	if err != nil {
+		return nil, err
+	}
+	qfp, err := find(filepath.Join(root, "usgs_streamflow", "*", gauge+"_streamflow_qc.txt"))
+	if err != nil {
+		return nil, err
+	}
+	return ReadCAMELS(ffp, qfp)
+}
+
+// ReadCAMELS reads a CAMELS basin-mean forcing file and its USGS streamflow file into a Table
+// dated by the forcings. Forcing columns keep their CAMELS names (e.g., "prcp(mm/day)", "tmax(C)");
+// streamflow is given as "Flow" (m³/s) and "QObs(mm/d)" (normalized by the basin area found in the
+// forcing file header). Missing streamflow (-999, or dates absent from the streamflow file) is NaN;
+// USGS qualifiers are parsed to flags: "A" NoFlag, "A:e" Estimate, "P" RealtimeUncorrected (provisional).
+func ReadCAMELS(forcingfp, streamflowfp string) (*Table, error) {
+	f, err := os.Open(forcingfp)
+	if err != nil {
+		return nil, fmt.Errorf("ReadCAMELS: %v", err)
+	}
+	defer f.Close()
+	t, area, err := parseCAMELSforcing(f)
+	if err != nil {
+		return nil, fmt.Errorf("ReadCAMELS %s: %v", forcingfp, err)
+	}
+
+	g, err := os.Open(streamflowfp)
+	if err != nil {
+		return nil, fmt.Errorf("ReadCAMELS: %v", err)
+	}
+	defer g.Close()
+	if err := t.parseCAMELSstreamflow(g, area); err != nil {
+		return nil, fmt.Errorf("ReadCAMELS %s: %v", streamflowfp, err)
+	}
+	return t, nil
+}
+
+// parseCAMELSforcing reads a forcing file: 3 header lines (latitude, elevation, area in m²),
+// a row of column names, then whitespace-delimited rows "Year Mnth Day Hr <forcings..>"
+func parseCAMELSforcing(r io.Reader) (*Table, float64, error) {
+	sc := bufio.NewScanner(r)
+	var hdr []string
+	for i := 0; i < 4 && sc.Scan(); i++ {
+		hdr = append(hdr, sc.Text())
+	}
+	if len(hdr) < 4 {
+		return nil, 0., fmt.Errorf("incomplete header")
+	}
+	area, err := strconv.ParseFloat(strings.TrimSpace(hdr[2]), 64)
+	if err != nil {
+		return nil, 0., fmt.Errorf("area read fail: %v", err)
+	}
+	cols := strings.Fields(hdr[3])
+	if len(cols) < 5 {
+		return nil, 0., fmt.Errorf("unexpected column header: %s", hdr[3])
+	}
+	t := &Table{Columns: cols[4:], Data: make([][]float64, len(cols)-4)}
+	for ln := 5; sc.Scan(); ln++ {
+		sp := strings.Fields(sc.Text())
+		if len(sp) == 0 {
+			continue
+		}
+		if len(sp) != len(cols) {
+			return nil, 0., fmt.Errorf("line %d: expecting %d values, found %d", ln, len(cols), len(sp))
+		}
+		d, err := parseYMD(sp[0], sp[1], sp[2])
+		if err != nil {
+			return nil, 0., fmt.Errorf("line %d: date read fail: %v", ln, err)
+		}
+		t.Time = append(t.Time, d)
+		t.Flag = append(t.Flag, NoFlag)
+		for k := range t.Columns {
+			v, err := parseValue(sp[4+k])
+			if err != nil {
+				return nil, 0., fmt.Errorf("line %d: column %s: %v", ln, t.Columns[k], err)
+			}
+			t.Data[k] = append(t.Data[k], v)
+		}
+	}
+	return t, area, sc.Err()
+}
+
+// parseCAMELSstreamflow adds the streamflow columns from rows "gauge year month day Q(cfs) qualifier"
+func (t *Table) parseCAMELSstreamflow(r io.Reader, area float64) error {
+	const cfs = .0283168466 // m³/s
+	idx := make(map[time.Time]int, t.Len())
+	for i, d := range t.Time {
+		idx[d] = i
+	}
+	q, qmm := make([]float64, t.Len()), make([]float64, t.Len())
+	for i := range q {
+		q[i], qmm[i] = math.NaN(), math.NaN()
+	}
+	sc := bufio.NewScanner(r)
+	for ln := 1; sc.Scan(); ln++ {
+		sp := strings.Fields(sc.Text())
+		if len(sp) == 0 {
+			continue
+		}
+		if len(sp) < 5 {
+			return fmt.Errorf("line %d: expecting at least 5 values, found %d", ln, len(sp))
+		}
+		d, err := parseYMD(sp[1], sp[2], sp[3])
+		if err != nil {
+			return fmt.Errorf("line %d: date read fail: %v", ln, err)
+		}
+		i, ok := idx[d]
+		if !ok {
+			continue // outside the forcing record
+		}
+		v, err := parseValue(sp[4])
+		if err != nil {
+			return fmt.Errorf("line %d: %v", ln, err)
+		}
+		if v >= 0. { // -999: missing
+			q[i] = v * cfs
+			qmm[i] = q[i] * 86400. / area * 1000.
+		}
+		if len(sp) > 5 {
+			t.Flag[i] = usgsFlag(sp[5])
+		}
+	}
+	t.Columns = append(t.Columns, "Flow", "QObs(mm/d)")
+	t.Data = append(t.Data, q, qmm)
+	return sc.Err()
+}
+
+// usgsFlag converts a USGS streamflow qualifier to a Flag
+func usgsFlag(s string) Flag {
+	switch {
+	case s == "A":
+		return NoFlag
+	case strings.HasSuffix(s, ":e"):
+		return Estimate
+	case s == "P":
+		return RealtimeUncorrected
+	}
+	return OtherFlag
+}
+
+func parseYMD(y, m, d string) (time.Time, error) {
+	yy, err := strconv.Atoi(y)
+	if err != nil {
+		return time.Time{}, err
+	}
+	mm, err := strconv.Atoi(m)
+	if err != nil {
+		return time.Time{}, err
+	}
+	dd, err := strconv.Atoi(d)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(yy, time.Month(mm), dd, 0, 0, 0, 0, time.UTC), nil
+}
+
+// ReadCAMELSAttributes reads and joins the semicolon-delimited CAMELS attribute tables found in
+// dir (camels_clim.txt, camels_topo.txt, ...; or only those named in files), keeping the numeric
+// attributes of gauges found in every table. Categorical attributes (e.g., geol_1st_class) are dropped.
+func ReadCAMELSAttributes(dir string, files ...string) (*Attributes, error) {
+	if len(files) == 0 {
+		files = []string{"camels_clim.txt", "camels_geol.txt", "camels_hydro.txt", "camels_soil.txt", "camels_topo.txt", "camels_vege.txt"}
+	}
+	a := &Attributes{}
+	for _, fn := range files {
+		fp := filepath.Join(dir, fn)
+		f, err := os.Open(fp)
+		if err != nil {
+			return nil, fmt.Errorf("ReadCAMELSAttributes: %v", err)
+		}
+		r := csv.NewReader(f)
+		r.Comma = ';'
+		b, err := parseAttributes(r, true)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("ReadCAMELSAttributes %s: %v", fp, err)
+		}
+		a.merge(b)
+	}
+	return a, nil
+}
@@ -0,0 +1,87 @@
+package goann
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// camelsFixture writes a one-gauge CAMELS layout (see ReadCAMELSBasin) under a temporary root
+func camelsFixture(t *testing.T) string {
+	root := t.TempDir()
+	files := map[string]string{
+		filepath.Join("basin_mean_forcing", "daymet", "01", "01013500_lump_cida_forcing_leap.txt"): `47.24
+ 250
+ 2252699550
+Year Mnth Day Hr	dayl(s)	prcp(mm/day)	srad(W/m2)	swe(mm)	tmax(C)	tmin(C)	vp(Pa)
+1980 01 01 12	30172.51	0.00	153.40	0.00	-6.54	-16.30	171.69
+1980 01 02 12	30253.15	0.00	145.27	0.00	-6.18	-15.22	185.94
+1980 01 03 12	30344.01	1.50	146.96	0.00	-9.89	-18.86	138.39
+1980 01 04 12	30408.20	4.20	150.11	0.00	-3.10	-11.25	190.21
+`,
+		filepath.Join("usgs_streamflow", "01", "01013500_streamflow_qc.txt"): `01013500 1980 01 01     655.00 A
+01013500 1980 01 02    -999.00 M
+01013500 1980 01 04     600.00 A:e
+01013500 1980 01 05     610.00 A
+`,
+		"camels_clim.txt": "gauge_id;p_mean;high_prec_timing\n01013500;3.1;son\n01022500;2.2;jja\n",
+		"camels_topo.txt": "gauge_id;elev_mean;area_gages2\n01013500;250;2252.7\n",
+	}
+	for fn, s := range files {
+		fp := filepath.Join(root, fn)
+		if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fp, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestReadCAMELSBasin(t *testing.T) {
+	root := camelsFixture(t)
+	tb, err := ReadCAMELSBasin(root, "daymet", "01013500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tb.Len() != 4 {
+		t.Fatalf("read %d timesteps, expecting 4 (dated by the forcings)", tb.Len())
+	}
+	p, err := tb.Column("prcp(mm/day)")
+	if err != nil || p[2] != 1.5 || p[3] != 4.2 {
+		t.Errorf("prcp %v %v", p, err)
+	}
+	q, _ := tb.Column("Flow")
+	if math.Abs(q[0]-655.*.0283168466) > 1e-9 || !math.IsNaN(q[1]) || !math.IsNaN(q[2]) || math.Abs(q[3]-600.*.0283168466) > 1e-9 {
+		t.Errorf("Flow %v", q)
+	}
+	qmm, _ := tb.Column("QObs(mm/d)")
+	if want := q[0] * 86400. / 2252699550. * 1000.; math.Abs(qmm[0]-want) > 1e-9 {
+		t.Errorf("QObs %g, expecting %g", qmm[0], want)
+	}
+	if tb.Flag[0] != NoFlag || tb.Flag[3] != Estimate {
+		t.Errorf("flags %v", tb.Flag)
+	}
+	if _, err := ReadCAMELSBasin(root, "daymet", "01022500"); err == nil {
+		t.Error("missing gauge found")
+	}
+}
+
+func TestReadCAMELSAttributes(t *testing.T) {
+	a, err := ReadCAMELSAttributes(camelsFixture(t), "camels_clim.txt", "camels_topo.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Names) != 3 || len(a.Values) != 1 { // categorical high_prec_timing dropped, 01022500 absent from topo
+		t.Fatalf("attributes %v of %d gauges", a.Names, len(a.Values))
+	}
+	v, err := a.Get("01013500")
+	if err != nil || v[0] != 3.1 || v[2] != 2252.7 {
+		t.Errorf("01013500: %v %v", v, err)
+	}
+	if _, err := a.Get("01022500"); err == nil {
+		t.Error("gauge missing from a table found")
+	}
+}
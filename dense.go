@@ -0,0 +1,104 @@
+package goann
+
+import "math/rand"
+
+// Dense is a fully-connected (regression) layer mapping a hidden state to ny outputs
+type Dense struct {
+	W      [][]float64 // weights (ny x nx)
+	B      []float64   // biases (ny)
+	dW     [][]float64
+	dB     []float64
+	p, d   []float64 // flat buffers holding all of the above (parameters and diffs)
+	act    Activation
+	nx, ny int
+	rate   float64 // dropout rate applied to the layer input
+	mc     bool    // dropout left on at inference (Monte Carlo dropout)
+}
+
+// denseCache holds what the layer needs, at a single timestep, to back-propagate
+type denseCache struct{ x, z, m []float64 }
+
+func newDense(nx, ny int, act Activation) Dense {
+	d := Dense{
+		p:   make([]float64, ny*(nx+1)),
+		d:   make([]float64, ny*(nx+1)),
+		act: act,
+		nx:  nx,
+		ny:  ny,
+	}
+	for i := 0; i < ny*nx; i++ { // biases start at zero
+		d.p[i] = rand.Float64()*0.2 - 0.1
+	}
+	pp, dd := d.p, d.d
+	d.W, d.B = carve(&pp, ny, nx), carveVec(&pp, ny)
+	d.dW, d.dB = carve(&dd, ny, nx), carveVec(&dd, ny)
+	return d
+}
+
+// forward returns the pre-activation (z) and output (y)
+func (d *Dense) forward(x []float64) (z, y []float64) {
+	z, y = make([]float64, d.ny), make([]float64, d.ny)
+	for k := 0; k < d.ny; k++ {
+		z[k] = dot(d.W[k], x) + d.B[k]
+		y[k] = d.act.f(z[k])
+	}
+	return
+}
+
+// backward accumulates diffs given the loss gradient w.r.t. the output (dy), returning the gradient w.r.t. x
+func (d *Dense) backward(x, z, dy []float64) []float64 {
+	dx := make([]float64, d.nx)
+	for k := 0; k < d.ny; k++ {
+		dz := dy[k] * d.act.prime(z[k])
+		d.dB[k] += dz
+		for i := 0; i < d.nx; i++ {
+			d.dW[k][i] += dz * x[i]
+			dx[i] += d.W[k][i] * dz
+		}
+	}
+	return dx
+}
+
+// dropout returns a copy of x where units are zeroed with probability rate, and survivors are scaled
+// by 1/(1-rate) (inverted dropout), along with the mask applied. With no dropout, x is returned as is.
+func (d *Dense) dropout(x []float64) (xd, m []float64) {
+	if d.rate <= 0. {
+		return x, nil
+	}
+	xd, m = make([]float64, len(x)), make([]float64, len(x))
+	for i := range x {
+		if rand.Float64() >= d.rate {
+			m[i] = 1. / (1. - d.rate)
+			xd[i] = x[i] * m[i]
+		}
+	}
+	return
+}
+
+// train forward propagates x (dropout on), saving to c what grad needs; returns the output
+func (d *Dense) train(c *denseCache, x []float64) []float64 {
+	var y []float64
+	c.x, c.m = d.dropout(x)
+	c.z, y = d.forward(c.x)
+	return y
+}
+
+// grad back-propagates dy through the forward pass saved in c, returning the gradient w.r.t. x
+func (d *Dense) grad(c *denseCache, dy []float64) []float64 {
+	dx := d.backward(c.x, c.z, dy)
+	for i, m := range c.m {
+		dx[i] *= m
+	}
+	return dx
+}
+
+// predict inference: dropout is only applied when Monte Carlo sampling
+func (d *Dense) predict(x []float64) []float64 {
+	if d.mc {
+		x, _ = d.dropout(x)
+	}
+	_, y := d.forward(x)
+	return y
+}
+
+func (d *Dense) params() Param { return Param{W: d.p, D: d.d} }
@@ -0,0 +1,339 @@
+package goann
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Distribution is a probabilistic output head: the network (with Linear outputs) predicts the
+// NParams raw parameters of a predictive distribution of a single target, trained by its Loss.
+type Distribution interface {
+	Loss
+	NParams() int                              // number of network outputs required
+	Mean(raw []float64) float64                // expected value
+	Quantile(raw []float64, p float64) float64 // inverse cumulative distribution, 0<p<1
+	Sample(raw []float64) float64              // random draw
+}
+
+var (
+	_ Distribution = GaussianNLL{}
+	_ Distribution = GMM{}
+	_ Distribution = CMAL{}
+	_ Distribution = Pinball{}
+)
+
+// Samples draws n values from the predictive distribution d, given the raw network output
+func Samples(d Distribution, raw []float64, n int) []float64 {
+	o := make([]float64, n)
+	for i := range o {
+		o[i] = d.Sample(raw)
+	}
+	return o
+}
+
+// Quantiles returns the values of the predictive distribution d at probabilities ps
+func Quantiles(d Distribution, raw []float64, ps []float64) []float64 {
+	o := make([]float64, len(ps))
+	for i, p := range ps {
+		o[i] = d.Quantile(raw, p)
+	}
+	return o
+}
+
+// GaussianNLL as a Distribution of a single target: raw = [mean, log-variance]
+func (GaussianNLL) NParams() int { return 2 }
+
+func (GaussianNLL) Mean(raw []float64) float64 { return raw[0] }
+
+func (GaussianNLL) Quantile(raw []float64, p float64) float64 {
+	return raw[0] + math.Exp(.5*raw[1])*math.Sqrt2*math.Erfinv(2.*p-1.)
+}
+
+func (GaussianNLL) Sample(raw []float64) float64 {
+	return raw[0] + math.Exp(.5*raw[1])*rand.NormFloat64()
+}
+
+// GMM mixture density network of K Gaussians (Bishop, 1994). raw = [K logits (mixture weights),
+// K means, K log-standard deviations].
+type GMM struct{ K int }
+
+func (m GMM) NParams() int { return 3 * m.K }
+
+// logf log-density of component k at y, and its derivatives w.r.t. the mean and log-sd
+func (m GMM) logf(raw []float64, k int, y float64) (lf, dmu, dls float64) {
+	mu, ls := raw[m.K+k], raw[2*m.K+k]
+	s := math.Exp(ls)
+	z := (y - mu) / s
+	return -.5*math.Log(2.*math.Pi) - ls - .5*z*z, z / s, -1. + z*z
+}
+
+func (m GMM) Loss(pred, label []float64) float64 {
+	if math.IsNaN(label[0]) {
+		return 0.
+	}
+	lf := make([]float64, m.K)
+	for k := range lf {
+		lf[k], _, _ = m.logf(pred, k, label[0])
+		lf[k] += pred[k]
+	}
+	return logSumExp(pred[:m.K]) - logSumExp(lf)
+}
+
+func (m GMM) Diff(pred, label []float64) []float64 {
+	o, lf, dmu, dls := make([]float64, len(pred)), make([]float64, m.K), make([]float64, m.K), make([]float64, m.K)
+	if math.IsNaN(label[0]) {
+		return o
+	}
+	for k := range lf {
+		lf[k], dmu[k], dls[k] = m.logf(pred, k, label[0])
+		lf[k] += pred[k]
+	}
+	pi, r := softmax(pred[:m.K]), softmax(lf) // prior weights and responsibilities
+	for k := 0; k < m.K; k++ {
+		o[k] = pi[k] - r[k]
+		o[m.K+k] = -r[k] * dmu[k]
+		o[2*m.K+k] = -r[k] * dls[k]
+	}
+	return o
+}
+
+func (m GMM) Mean(raw []float64) float64 {
+	s := 0.
+	for k, p := range softmax(raw[:m.K]) {
+		s += p * raw[m.K+k]
+	}
+	return s
+}
+
+func (m GMM) cdf(raw []float64, y float64) float64 {
+	s := 0.
+	for k, p := range softmax(raw[:m.K]) {
+		s += p * .5 * math.Erfc(-(y-raw[m.K+k])/math.Exp(raw[2*m.K+k])/math.Sqrt2)
+	}
+	return s
+}
+
+func (m GMM) Quantile(raw []float64, p float64) float64 {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for k := 0; k < m.K; k++ {
+		q := GaussianNLL{}.Quantile([]float64{raw[m.K+k], 2. * raw[2*m.K+k]}, p)
+		lo, hi = math.Min(lo, q), math.Max(hi, q)
+	}
+	return bisect(func(y float64) float64 { return m.cdf(raw, y) }, p, lo, hi)
+}
+
+func (m GMM) Sample(raw []float64) float64 {
+	k := pick(softmax(raw[:m.K]))
+	return raw[m.K+k] + math.Exp(raw[2*m.K+k])*rand.NormFloat64()
+}
+
+// CMAL countable mixture of K asymmetric Laplacians (Klotz et.al., 2022). raw = [K logits,
+// K locations, K log-scales, K asymmetries (passed through a sigmoid to give 0<tau<1)].
+type CMAL struct{ K int }
+
+func (m CMAL) NParams() int { return 4 * m.K }
+
+// ald returns the location, scale and asymmetry of component k
+func (m CMAL) ald(raw []float64, k int) (mu, b, tau float64) {
+	return raw[m.K+k], math.Exp(raw[2*m.K+k]), sigmoid(raw[3*m.K+k])
+}
+
+// logf log-density of component k at y, and its derivatives w.r.t. the raw location, log-scale and asymmetry
+func (m CMAL) logf(raw []float64, k int, y float64) (lf, dmu, dlb, dtr float64) {
+	mu, b, tau := m.ald(raw, k)
+	u := (y - mu) / b
+	ind := 0.
+	if u < 0. {
+		ind = 1.
+	}
+	rho := u * (tau - ind) // check (pinball) function
+	lf = math.Log(tau) + math.Log(1.-tau) - raw[2*m.K+k] - rho
+	return lf, (tau - ind) / b, -1. + rho, 1. - 2.*tau - u*tau*(1.-tau)
+}
+
+func (m CMAL) Loss(pred, label []float64) float64 {
+	if math.IsNaN(label[0]) {
+		return 0.
+	}
+	lf := make([]float64, m.K)
+	for k := range lf {
+		lf[k], _, _, _ = m.logf(pred, k, label[0])
+		lf[k] += pred[k]
+	}
+	return logSumExp(pred[:m.K]) - logSumExp(lf)
+}
+
+func (m CMAL) Diff(pred, label []float64) []float64 {
+	o, lf := make([]float64, len(pred)), make([]float64, m.K)
+	dmu, dlb, dtr := make([]float64, m.K), make([]float64, m.K), make([]float64, m.K)
+	if math.IsNaN(label[0]) {
+		return o
+	}
+	for k := range lf {
+		lf[k], dmu[k], dlb[k], dtr[k] = m.logf(pred, k, label[0])
+		lf[k] += pred[k]
+	}
+	pi, r := softmax(pred[:m.K]), softmax(lf)
+	for k := 0; k < m.K; k++ {
+		o[k] = pi[k] - r[k]
+		o[m.K+k] = -r[k] * dmu[k]
+		o[2*m.K+k] = -r[k] * dlb[k]
+		o[3*m.K+k] = -r[k] * dtr[k]
+	}
+	return o
+}
+
+func (m CMAL) Mean(raw []float64) float64 {
+	s := 0.
+	for k, p := range softmax(raw[:m.K]) {
+		mu, b, tau := m.ald(raw, k)
+		s += p * (mu + b*(1.-2.*tau)/tau/(1.-tau))
+	}
+	return s
+}
+
+func (m CMAL) cdf(raw []float64, y float64) float64 {
+	s := 0.
+	for k, p := range softmax(raw[:m.K]) {
+		mu, b, tau := m.ald(raw, k)
+		u := (y - mu) / b
+		if u < 0. {
+			s += p * tau * math.Exp((1.-tau)*u)
+		} else {
+			s += p * (1. - (1.-tau)*math.Exp(-tau*u))
+		}
+	}
+	return s
+}
+
+// quantile of a single asymmetric Laplacian
+func aldQuantile(mu, b, tau, p float64) float64 {
+	if p < tau {
+		return mu + b/(1.-tau)*math.Log(p/tau)
+	}
+	return mu - b/tau*math.Log((1.-p)/(1.-tau))
+}
+
+func (m CMAL) Quantile(raw []float64, p float64) float64 {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for k := 0; k < m.K; k++ {
+		mu, b, tau := m.ald(raw, k)
+		q := aldQuantile(mu, b, tau, p)
+		lo, hi = math.Min(lo, q), math.Max(hi, q)
+	}
+	return bisect(func(y float64) float64 { return m.cdf(raw, y) }, p, lo, hi)
+}
+
+func (m CMAL) Sample(raw []float64) float64 {
+	mu, b, tau := m.ald(raw, pick(softmax(raw[:m.K])))
+	return aldQuantile(mu, b, tau, 1.-rand.Float64()) // (0,1]
+}
+
+// Pinball multi-quantile (pinball) loss: raw holds one predicted value per quantile level in Taus
+type Pinball struct{ Taus []float64 }
+
+func (m Pinball) NParams() int { return len(m.Taus) }
+
+func (m Pinball) Loss(pred, label []float64) float64 {
+	s := 0.
+	if math.IsNaN(label[0]) {
+		return s
+	}
+	for k, tau := range m.Taus {
+		u := label[0] - pred[k]
+		if u < 0. {
+			s += u * (tau - 1.)
+		} else {
+			s += u * tau
+		}
+	}
+	return s
+}
+
+func (m Pinball) Diff(pred, label []float64) []float64 {
+	o := make([]float64, len(pred))
+	if math.IsNaN(label[0]) {
+		return o
+	}
+	for k, tau := range m.Taus {
+		if label[0] < pred[k] {
+			o[k] = 1. - tau
+		} else {
+			o[k] = -tau
+		}
+	}
+	return o
+}
+
+// Mean approximated by the average of the predicted quantiles
+func (m Pinball) Mean(raw []float64) float64 {
+	s := 0.
+	for _, v := range raw[:len(m.Taus)] {
+		s += v
+	}
+	return s / float64(len(m.Taus))
+}
+
+// Quantile linearly interpolates between predicted levels (sorted, such that quantiles never cross);
+// beyond the outermost levels, the outermost predictions are returned.
+func (m Pinball) Quantile(raw []float64, p float64) float64 {
+	n := len(m.Taus)
+	ts, qs := append([]float64(nil), m.Taus...), append([]float64(nil), raw[:n]...)
+	sort.Float64s(ts)
+	sort.Float64s(qs)
+	i := sort.SearchFloat64s(ts, p)
+	switch {
+	case i == 0:
+		return qs[0]
+	case i == n:
+		return qs[n-1]
+	}
+	return qs[i-1] + (qs[i]-qs[i-1])*(p-ts[i-1])/(ts[i]-ts[i-1])
+}
+
+func (m Pinball) Sample(raw []float64) float64 { return m.Quantile(raw, rand.Float64()) }
+
+func logSumExp(a []float64) float64 {
+	mx := math.Inf(-1)
+	for _, v := range a {
+		mx = math.Max(mx, v)
+	}
+	s := 0.
+	for _, v := range a {
+		s += math.Exp(v - mx)
+	}
+	return mx + math.Log(s)
+}
+
+func softmax(a []float64) []float64 {
+	o, l := make([]float64, len(a)), logSumExp(a)
+	for i, v := range a {
+		o[i] = math.Exp(v - l)
+	}
+	return o
+}
+
+// pick draws an index given its probabilities
+func pick(p []float64) int {
+	u := rand.Float64()
+	for i, v := range p {
+		if u -= v; u < 0. {
+			return i
+		}
+	}
+	return len(p) - 1
+}
+
+// bisect solves the monotone increasing cdf(y)=p, for y bracketed by [lo,hi]
+func bisect(cdf func(float64) float64, p, lo, hi float64) float64 {
+	for i := 0; i < 100 && hi-lo > 1e-12*(1.+math.Abs(lo)); i++ {
+		mid := .5 * (lo + hi)
+		if cdf(mid) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return .5 * (lo + hi)
+}
@@ -0,0 +1,53 @@
+package goann
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// rawOutput returns deterministic raw network outputs for a distribution head
+func rawOutput(d Distribution) []float64 {
+	o := make([]float64, d.NParams())
+	for i := range o {
+		o[i] = .8 * math.Sin(1.7*float64(i)+.4)
+	}
+	return o
+}
+
+func TestDistributionDiff(t *testing.T) {
+	for _, d := range []Distribution{GaussianNLL{}, GMM{K: 3}, CMAL{K: 3}, Pinball{Taus: []float64{.1, .5, .9}}} {
+		for _, y := range []float64{-.9, .37, 1.4} {
+			diffCheck(t, fmt.Sprintf("%T", d), d, rawOutput(d), []float64{y})
+		}
+	}
+}
+
+func TestDistributionDensity(t *testing.T) {
+	for _, d := range []Distribution{GaussianNLL{}, GMM{K: 2}, CMAL{K: 2}} {
+		r, s := rawOutput(d), 0.
+		for y := -60.; y < 60.; y += .001 {
+			s += math.Exp(-d.Loss(r, []float64{y})) * .001
+		}
+		if math.Abs(s-1.) > 1e-3 {
+			t.Errorf("%T: density integrates to %g", d, s)
+		}
+	}
+}
+
+func TestDistributionQuantiles(t *testing.T) {
+	ps := []float64{.05, .25, .5, .75, .95}
+	for _, d := range []Distribution{GaussianNLL{}, GMM{K: 3}, CMAL{K: 3}, Pinball{Taus: []float64{.1, .5, .9}}} {
+		q := Quantiles(d, rawOutput(d), ps)
+		for i := 1; i < len(q); i++ {
+			if q[i] < q[i-1] {
+				t.Errorf("%T: quantiles %v not increasing", d, q)
+				break
+			}
+		}
+	}
+	g := GaussianNLL{}
+	if q := g.Quantile([]float64{1., 0.}, .5); math.Abs(q-1.) > 1e-9 {
+		t.Errorf("GaussianNLL: median %g, expecting 1", q)
+	}
+}
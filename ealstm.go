@@ -0,0 +1,204 @@
+package goann
+
+import (
+	"fmt"
+	"math"
+)
+
+// EALSTM entity-aware LSTM (Kratzert et.al., 2019): the input gate is controlled by the static
+// catchment attributes only (constant in time), while the dynamic forcings feed the remaining gates
+type EALSTM struct {
+	Wi             [][]float64 // static weights (nh x ns)
+	Wf, Wg, Wo     [][]float64 // input weights (nh x nx)
+	Uf, Ug, Uo     [][]float64 // recurrent weights (nh x nh)
+	Bi, Bf, Bg, Bo []float64   // biases (nh)
+	h, c, static   []float64
+
+	// diffs (derivative of loss function w.r.t. all parameters)
+	dWi                [][]float64
+	dWf, dWg, dWo      [][]float64
+	dUf, dUg, dUo      [][]float64
+	dBi, dBf, dBg, dBo []float64
+	p, d               []float64 // flat buffers holding all of the above (parameters and diffs)
+
+	head       Dense        // output layer
+	cache      []lstmCache  // [timestep]
+	hc         []denseCache // [timestep]
+	eta        float64
+	nx, ns, nh int
+	trunc      int // BPTT truncation length (<=0: full sequence)
+}
+
+// TruncateBPTT limits back-propagation through time to blocks of n timesteps (n<=0: no truncation)
+func (ea *EALSTM) TruncateBPTT(n int) { ea.trunc = n }
+
+// SetStatic sets the static catchment attributes of the basin being simulated. Returns an error
+// when their number differs from that the network was built with.
+func (ea *EALSTM) SetStatic(static []float64) error {
+	if len(static) != ea.ns {
+		return fmt.Errorf("%d static attributes given, network expects %d", len(static), ea.ns)
+	}
+	ea.static = append(ea.static[:0], static...)
+	return nil
+}
+
+// Reset zeroes the hidden and cell states
+func (ea *EALSTM) Reset() {
+	for j := 0; j < ea.nh; j++ {
+		ea.h[j] = 0.
+		ea.c[j] = 0.
+	}
+}
+
+// inputGate computed from the static attributes
+func (ea *EALSTM) inputGate() []float64 {
+	i := make([]float64, ea.nh)
+	for j := 0; j < ea.nh; j++ {
+		i[j] = sigmoid(dot(ea.Wi[j], ea.static) + ea.Bi[j])
+	}
+	return i
+}
+
+// update advances the cell one timestep, returning the activated gates
+func (ea *EALSTM) update(x, i []float64) (g, f, o []float64) {
+	g, f, o = make([]float64, ea.nh), make([]float64, ea.nh), make([]float64, ea.nh)
+	for j := 0; j < ea.nh; j++ {
+		g[j] = math.Tanh(dot(ea.Wg[j], x) + dot(ea.Ug[j], ea.h) + ea.Bg[j]) // candidate state
+		f[j] = sigmoid(dot(ea.Wf[j], x) + dot(ea.Uf[j], ea.h) + ea.Bf[j])   // forget gate
+		o[j] = sigmoid(dot(ea.Wo[j], x) + dot(ea.Uo[j], ea.h) + ea.Bo[j])   // output gate
+	}
+	for j := 0; j < ea.nh; j++ {
+		ea.c[j] = f[j]*ea.c[j] + i[j]*g[j]  // update cell state
+		ea.h[j] = math.Tanh(ea.c[j]) * o[j] // update hidden state
+	}
+	return
+}
+
+// Forward propagates a sequence of dynamic forcings from the current state, saving recursive states for back-propagation
+func (ea *EALSTM) Forward(input [][]float64) [][]float64 {
+	ypred := make([][]float64, len(input))
+	ea.cache = make([]lstmCache, len(input))
+	ea.hc = make([]denseCache, len(input))
+	i := ea.inputGate()
+	for t, x := range input {
+		s := &ea.cache[t]
+		s.x, s.i = x, i
+		s.c0, s.h0 = append([]float64(nil), ea.c...), append([]float64(nil), ea.h...)
+		s.g, s.f, s.o = ea.update(x, i)
+		s.c = append([]float64(nil), ea.c...)
+
+		// prediction
+		ypred[t] = ea.head.train(&ea.hc[t], append([]float64(nil), ea.h...))
+	}
+	return ypred
+}
+
+// Backward back-propagates through the output layer and through time. dy: loss gradient
+// w.r.t. each prediction made in the last call to Forward (nil: not in loss).
+func (ea *EALSTM) Backward(dy [][]float64) {
+	nt := len(dy)
+	dh, dc, di := make([]float64, ea.nh), make([]float64, ea.nh), make([]float64, ea.nh) // di: input gate diffs, summed over time
+	for t := nt - 1; t >= 0; t-- {
+		if t == nt-1 || (ea.trunc > 0 && (nt-1-t)%ea.trunc == 0) {
+			// here h(t+1) and c(t+1) are not affecting loss (end of sequence, or truncated)
+			dh, dc = make([]float64, ea.nh), make([]float64, ea.nh)
+		}
+		if dy[t] != nil {
+			dx := ea.head.grad(&ea.hc[t], dy[t])
+			for j := range dx {
+				dh[j] += dx[j]
+			}
+		}
+
+		s := &ea.cache[t]
+		dh0, dc0 := make([]float64, ea.nh), make([]float64, ea.nh)
+		for j := 0; j < ea.nh; j++ {
+			tc := math.Tanh(s.c[j])
+			dcj := dc[j] + dh[j]*s.o[j]*(1.-tc*tc)
+			dc0[j] = dcj * s.f[j] // constant error carousel
+
+			// diffs w.r.t. vector inside sigma / tanh function
+			do := dh[j] * tc * s.o[j] * (1. - s.o[j])
+			df := dcj * s.c0[j] * s.f[j] * (1. - s.f[j])
+			dg := dcj * s.i[j] * (1. - s.g[j]*s.g[j])
+			di[j] += dcj * s.g[j] * s.i[j] * (1. - s.i[j])
+
+			ea.dBo[j] += do
+			ea.dBf[j] += df
+			ea.dBg[j] += dg
+			for k := 0; k < ea.nx; k++ {
+				ea.dWo[j][k] += do * s.x[k]
+				ea.dWf[j][k] += df * s.x[k]
+				ea.dWg[j][k] += dg * s.x[k]
+			}
+			for k := 0; k < ea.nh; k++ {
+				ea.dUo[j][k] += do * s.h0[k]
+				ea.dUf[j][k] += df * s.h0[k]
+				ea.dUg[j][k] += dg * s.h0[k]
+				dh0[k] += ea.Uo[j][k]*do + ea.Uf[j][k]*df + ea.Ug[j][k]*dg
+			}
+		}
+		dh, dc = dh0, dc0
+	}
+
+	// static input gate
+	for j := 0; j < ea.nh; j++ {
+		ea.dBi[j] += di[j]
+		for k := 0; k < ea.ns; k++ {
+			ea.dWi[j][k] += di[j] * ea.static[k]
+		}
+	}
+}
+
+// Params returns the parameters of the recurrent layer and of the output layer
+func (ea *EALSTM) Params() []Param {
+	return []Param{{W: ea.p, D: ea.d}, ea.head.params()}
+}
+
+// Train a basin's forcing and target sequences, given its static attributes and weighted by
+// Basin.Weight, back-propagating the error e = pred-label (as LSTMlayers.Train). Returns the sum
+// of squared errors.
+func (ea *EALSTM) Train(b Basin) (float64, error) {
+	if err := b.check(); err != nil {
+		return 0., fmt.Errorf("Train: %v", err)
+	}
+	if err := ea.SetStatic(b.Static); err != nil {
+		return 0., fmt.Errorf("Train: basin %s: %v", b.ID, err)
+	}
+	return trainSequence(ea, b.Input, b.Target, b.Weight, halfMSE{}, ea.eta), nil
+}
+
+// TrainLookback trains nbatch mini-batches of randomly sampled lookback windows of a basin, weighted
+// by Basin.Weight (see LSTMlayers.TrainLookback)
+func (ea *EALSTM) TrainLookback(b Basin, lb Lookback, nbatch int) (float64, error) {
+	if err := ea.SetStatic(b.Static); err != nil {
+		return 0., fmt.Errorf("TrainLookback: basin %s: %v", b.ID, err)
+	}
+	return TrainLookbackWeighted(ea, b.Input, b.Target, b.Weight, lb, nbatch, ea.eta)
+}
+
+// Step advances the (trained) network one timestep from its current state, returning the prediction.
+// Weights are left untouched.
+func (ea *EALSTM) Step(x []float64) []float64 {
+	ea.update(x, ea.inputGate())
+	return ea.head.predict(ea.h)
+}
+
+// Predict runs the (trained) network over a basin's forcings, starting from rest. The state is
+// left at the end of the sequence, such that it can be snapshot (State) and advanced using Step.
+func (ea *EALSTM) Predict(b Basin) ([][]float64, error) {
+	if err := ea.SetStatic(b.Static); err != nil {
+		return nil, fmt.Errorf("Predict: basin %s: %v", b.ID, err)
+	}
+	return ea.predict(b.Input), nil
+}
+
+// predict runs the network over a sequence of forcings from rest, given the static attributes set
+func (ea *EALSTM) predict(input [][]float64) [][]float64 {
+	ea.Reset()
+	o := make([][]float64, len(input))
+	for t, x := range input {
+		o[t] = ea.Step(x)
+	}
+	return o
+}
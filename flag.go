@@ -0,0 +1,76 @@
+package goann
+
+import (
+	"fmt"
+	"math"
+)
+
+// FlagPolicy sets how flagged observations are treated in training and evaluation, given as the
+// loss weight of every flag: 0 drops the observation, 0<w<1 down-weights it, and flags not in
+// the policy are kept (weight 1). For example, FlagPolicy{IceConditions: 0, Estimate: .5}.
+type FlagPolicy map[Flag]float64
+
+// Weight returns the loss weight of an observation having flag f
+func (p FlagPolicy) Weight(f Flag) float64 {
+	if w, ok := p[f]; ok {
+		return w
+	}
+	return 1.
+}
+
+// Weights returns the loss weight of every timestep, given their flags
+func (p FlagPolicy) Weights(flags []Flag) []float64 {
+	o := make([]float64, len(flags))
+	for t, f := range flags {
+		o[t] = p.Weight(f)
+	}
+	return o
+}
+
+// Mask returns a copy of trainer (sequence of target vectors) where observations dropped by the
+// policy (weight 0) are set missing (NaN), such that any trainer excludes them from the loss. Nil
+// flags are taken as NoFlag; an error is returned when the flags and trainer differ in length.
+func (p FlagPolicy) Mask(trainer [][]float64, flags []Flag) ([][]float64, error) {
+	if flags != nil && len(flags) != len(trainer) {
+		return nil, fmt.Errorf("FlagPolicy.Mask: trainer (%d) and flag (%d) lengths differ", len(trainer), len(flags))
+	}
+	o := make([][]float64, len(trainer))
+	for t, y := range trainer {
+		o[t] = append([]float64(nil), y...)
+		f := NoFlag
+		if flags != nil {
+			f = flags[t]
+		}
+		if p.Weight(f) == 0. {
+			for k := range o[t] {
+				o[t][k] = math.NaN()
+			}
+		}
+	}
+	return o, nil
+}
+
+// Apply sets the loss weights of a basin according to its flags (multiplying any existing
+// weights). Targets are left untouched, such that flagged periods can still be evaluated. Returns an
+// error when the flags do not match the basin's record in length.
+func (p FlagPolicy) Apply(b *Basin) error {
+	if b.Flag == nil {
+		return nil
+	}
+	if len(b.Flag) != len(b.Input) {
+		return fmt.Errorf("FlagPolicy.Apply: basin %s: input (%d) and flag (%d) lengths differ", b.ID, len(b.Input), len(b.Flag))
+	}
+	if b.Weight != nil && len(b.Weight) != len(b.Flag) {
+		return fmt.Errorf("FlagPolicy.Apply: basin %s: flag (%d) and weight (%d) lengths differ", b.ID, len(b.Flag), len(b.Weight))
+	}
+	if b.Weight == nil {
+		b.Weight = make([]float64, len(b.Flag))
+		for t := range b.Weight {
+			b.Weight[t] = 1.
+		}
+	}
+	for t, f := range b.Flag {
+		b.Weight[t] *= p.Weight(f)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package goann
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFlagPolicyApply(t *testing.T) {
+	p := FlagPolicy{IceConditions: 0., Estimate: .5}
+	xs := seq(4, 1, 1., 0.)
+	b := Basin{ID: "02EC018", Input: xs, Target: xs, Flag: []Flag{NoFlag, IceConditions, Estimate, Partial}}
+	if err := p.Apply(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !equalFloats(b.Weight, []float64{1., 0., .5, 1.}) {
+		t.Errorf("weights %v", b.Weight)
+	}
+	if err := p.Apply(&b); err != nil || !equalFloats(b.Weight, []float64{1., 0., .25, 1.}) {
+		t.Errorf("weights applied twice %v %v", b.Weight, err)
+	}
+
+	b.Weight = []float64{1.}
+	if err := p.Apply(&b); err == nil {
+		t.Error("short weights accepted")
+	}
+	b.Weight, b.Flag = nil, b.Flag[:2]
+	if err := p.Apply(&b); err == nil {
+		t.Error("short flags accepted")
+	}
+}
+
+func TestFlagPolicyMask(t *testing.T) {
+	p := FlagPolicy{IceConditions: 0.}
+	y, err := p.Mask([][]float64{{1.}, {2.}, {3.}}, []Flag{NoFlag, IceConditions, Estimate})
+	if err != nil || y[0][0] != 1. || !math.IsNaN(y[1][0]) || y[2][0] != 3. {
+		t.Errorf("masked %v %v", y, err)
+	}
+	if y, err := (FlagPolicy{}).Mask([][]float64{{1.}, {2.}}, nil); err != nil || len(y) != 2 || y[1][0] != 2. {
+		t.Errorf("nil flags: %v %v", y, err)
+	}
+	if _, err := p.Mask([][]float64{{1.}, {2.}}, []Flag{NoFlag}); err == nil {
+		t.Error("short flags accepted")
+	}
+}
+
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
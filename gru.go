@@ -0,0 +1,207 @@
+package goann
+
+import "math"
+
+// GRU gated recurrent unit (Cho et.al., 2014): update (z) and reset (r) gates, no separate cell state
+type GRU struct {
+	Wz, Wr, Wn [][]float64 // input weights (nh x nx)
+	Uz, Ur, Un [][]float64 // recurrent weights (nh x nh)
+	Bz, Br, Bn []float64   // biases (nh)
+	h          []float64
+
+	// diffs (derivative of loss function w.r.t. all parameters)
+	dWz, dWr, dWn [][]float64
+	dUz, dUr, dUn [][]float64
+	dBz, dBr, dBn []float64
+	p, d          []float64 // flat buffers holding all of the above (parameters and diffs)
+	nx, nh        int
+}
+
+// gruCache holds what a single cell needs, at a single timestep, to back-propagate
+type gruCache struct{ x, z, r, n, h0 []float64 }
+
+type GRUlayers struct {
+	layer []GRU
+	head  Dense        // output layer
+	cache [][]gruCache // [layer][timestep]
+	hc    []denseCache // [timestep]
+	eta   float64
+	nl    int
+	trunc int // BPTT truncation length (<=0: full sequence)
+}
+
+// TruncateBPTT limits back-propagation through time to blocks of n timesteps (n<=0: no truncation)
+func (gs *GRUlayers) TruncateBPTT(n int) { gs.trunc = n }
+
+// Reset zeroes the hidden states of all layers
+func (gs *GRUlayers) Reset() {
+	for i := 0; i < gs.nl; i++ {
+		for j := 0; j < gs.layer[i].nh; j++ {
+			gs.layer[i].h[j] = 0.
+		}
+	}
+}
+
+// update advances the cell one timestep, returning the activated gates and candidate state
+func (l *GRU) update(x []float64) (z, r, n []float64) {
+	z, r, n = make([]float64, l.nh), make([]float64, l.nh), make([]float64, l.nh)
+	rh := make([]float64, l.nh)
+	for j := 0; j < l.nh; j++ {
+		z[j] = sigmoid(dot(l.Wz[j], x) + dot(l.Uz[j], l.h) + l.Bz[j]) // update gate
+		r[j] = sigmoid(dot(l.Wr[j], x) + dot(l.Ur[j], l.h) + l.Br[j]) // reset gate
+		rh[j] = r[j] * l.h[j]
+	}
+	for j := 0; j < l.nh; j++ {
+		n[j] = math.Tanh(dot(l.Wn[j], x) + dot(l.Un[j], rh) + l.Bn[j]) // candidate state
+	}
+	for j := 0; j < l.nh; j++ {
+		l.h[j] = (1.-z[j])*n[j] + z[j]*l.h[j] // update hidden state
+	}
+	return
+}
+
+// backpropagate a single timestep. dh: loss gradient w.r.t. h(t) (from the layer above, the
+// prediction and from h(t+1)). Parameter diffs are accumulated; returned are the gradients
+// w.r.t. x(t) and h(t-1).
+func (l *GRU) backpropagate(s *gruCache, dh []float64) (dx, dh0 []float64) {
+	dx, dh0 = make([]float64, l.nx), make([]float64, l.nh)
+	dz, dn, drh := make([]float64, l.nh), make([]float64, l.nh), make([]float64, l.nh)
+
+	// candidate state and update gate (diffs w.r.t. vector inside sigma / tanh function)
+	for j := 0; j < l.nh; j++ {
+		dn[j] = dh[j] * (1. - s.z[j]) * (1. - s.n[j]*s.n[j])
+		dz[j] = dh[j] * (s.h0[j] - s.n[j]) * s.z[j] * (1. - s.z[j])
+		dh0[j] = dh[j] * s.z[j]
+	}
+	for j := 0; j < l.nh; j++ {
+		l.dBn[j] += dn[j]
+		l.dBz[j] += dz[j]
+		for k := 0; k < l.nx; k++ {
+			l.dWn[j][k] += dn[j] * s.x[k]
+			l.dWz[j][k] += dz[j] * s.x[k]
+			dx[k] += l.Wn[j][k]*dn[j] + l.Wz[j][k]*dz[j]
+		}
+		for k := 0; k < l.nh; k++ {
+			l.dUn[j][k] += dn[j] * s.r[k] * s.h0[k]
+			l.dUz[j][k] += dz[j] * s.h0[k]
+			drh[k] += l.Un[j][k] * dn[j]
+			dh0[k] += l.Uz[j][k] * dz[j]
+		}
+	}
+
+	// reset gate
+	for k := 0; k < l.nh; k++ {
+		dh0[k] += drh[k] * s.r[k]
+	}
+	for j := 0; j < l.nh; j++ {
+		dr := drh[j] * s.h0[j] * s.r[j] * (1. - s.r[j])
+		l.dBr[j] += dr
+		for k := 0; k < l.nx; k++ {
+			l.dWr[j][k] += dr * s.x[k]
+			dx[k] += l.Wr[j][k] * dr
+		}
+		for k := 0; k < l.nh; k++ {
+			l.dUr[j][k] += dr * s.h0[k]
+			dh0[k] += l.Ur[j][k] * dr
+		}
+	}
+	return
+}
+
+// Forward propagates an input sequence from the current state, saving recursive states for back-propagation
+func (gs *GRUlayers) Forward(input [][]float64) [][]float64 {
+	ypred := make([][]float64, len(input))
+	for k := 0; k < gs.nl; k++ {
+		gs.cache[k] = make([]gruCache, len(input))
+	}
+	gs.hc = make([]denseCache, len(input))
+	for j, v := range input {
+		x := v
+		for k := 0; k < gs.nl; k++ { // deep learning
+			l := &gs.layer[k]
+			s := &gs.cache[k][j]
+			s.x = x
+			s.h0 = append([]float64(nil), l.h...)
+			s.z, s.r, s.n = l.update(x)
+			x = append([]float64(nil), l.h...)
+		}
+
+		// prediction
+		ypred[j] = gs.head.train(&gs.hc[j], x)
+	}
+	return ypred
+}
+
+// Backward back-propagates through the output layer, through time and through the stacked layers.
+// dy: loss gradient w.r.t. each prediction made in the last call to Forward (nil: not in loss).
+func (gs *GRUlayers) Backward(dy [][]float64) {
+	nt := len(dy)
+	dhnext := make([][]float64, gs.nl)
+	for j := nt - 1; j >= 0; j-- {
+		if j == nt-1 || (gs.trunc > 0 && (nt-1-j)%gs.trunc == 0) {
+			// here h(t+1) is not affecting loss (end of sequence, or truncated)
+			for k := 0; k < gs.nl; k++ {
+				dhnext[k] = make([]float64, gs.layer[k].nh)
+			}
+		}
+		var dx []float64
+		if dy[j] != nil {
+			dx = gs.head.grad(&gs.hc[j], dy[j])
+		}
+		for k := gs.nl - 1; k >= 0; k-- {
+			dh := dhnext[k]
+			for i := range dx {
+				dh[i] += dx[i] // layer above (or prediction) plus h(t+1)
+			}
+			dx, dhnext[k] = gs.layer[k].backpropagate(&gs.cache[k][j], dh)
+		}
+	}
+}
+
+// Params returns the parameters of all layers, including the output layer
+func (gs *GRUlayers) Params() []Param {
+	ps := make([]Param, 0, gs.nl+1)
+	for k := 0; k < gs.nl; k++ {
+		ps = append(ps, Param{W: gs.layer[k].p, D: gs.layer[k].d})
+	}
+	return append(ps, gs.head.params())
+}
+
+// Train input: sequence of forcing vectors (one per timestep); trainer: sequence of target vectors.
+// The recurrent layers and the output layer are trained jointly, back-propagating the error e = pred-label
+// (i.e., half the gradient of MSE, as LSTMlayers.Train). Returns the sum of squared errors.
+func (gs *GRUlayers) Train(input, trainer [][]float64) float64 {
+	return TrainSequence(gs, input, trainer, halfMSE{}, gs.eta)
+}
+
+// TrainWeighted as Train, where the loss of every timestep is scaled by its weight (nil: 1). Returns an
+// error when the input, trainer and weight lengths differ.
+func (gs *GRUlayers) TrainWeighted(input, trainer [][]float64, wts []float64) (float64, error) {
+	return TrainSequenceWeighted(gs, input, trainer, wts, halfMSE{}, gs.eta)
+}
+
+// TrainLookback trains nbatch mini-batches of randomly sampled lookback windows (see LSTMlayers.TrainLookback)
+func (gs *GRUlayers) TrainLookback(input, trainer [][]float64, lb Lookback, nbatch int) (float64, error) {
+	return TrainLookback(gs, input, trainer, lb, nbatch, gs.eta)
+}
+
+// Step advances the (trained) network one timestep from its current state, returning the prediction.
+// Weights are left untouched.
+func (gs *GRUlayers) Step(x []float64) []float64 {
+	for k := 0; k < gs.nl; k++ {
+		gs.layer[k].update(x)
+		x = gs.layer[k].h
+	}
+	return gs.head.predict(x)
+}
+
+// Predict runs the (trained) network over an input sequence, starting from rest. The state is
+// left at the end of the sequence, such that it can be snapshot (State) and advanced using Step.
+func (gs *GRUlayers) Predict(input [][]float64) [][]float64 {
+	gs.Reset()
+	o := make([][]float64, len(input))
+	for j, x := range input {
+		o[j] = gs.Step(x)
+	}
+	return o
+}
@@ -0,0 +1,119 @@
+package goann
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// HYDAT, the Water Survey of Canada national hydrometric archive, is distributed as an SQLite
+// database (Hydat.sqlite3). Daily flows are stored in wide monthly rows of table DLY_FLOWS:
+//  STATION_NUMBER, YEAR, MONTH, FULL_MONTH, NO_DAYS, MONTHLY_MEAN, ..., FLOW1, FLOW_SYMBOL1, ..., FLOW31, FLOW_SYMBOL31
+// The database is opened by the caller, using any database/sql SQLite driver (this package
+// remains free of dependencies), e.g.:
+//  db, err := sql.Open("sqlite", "Hydat.sqlite3") // import _ "modernc.org/sqlite"
+
+// ReadHYDAT returns the daily flows (column "Flow", m³/s) of a WSC station (e.g., "02EC018") from
+// start to end inclusive, one row per day, where days missing from HYDAT are NaN. HYDAT symbols are
+// parsed to flags: "B" IceConditions (backwater), "E" Estimate, "A" Partial (partial day), "D" (dry)
+// OtherFlag. A zero start (end) is taken as the beginning (end) of the station record.
+func ReadHYDAT(db *sql.DB, station string, start, end time.Time) (*Table, error) {
+	if start.IsZero() || end.IsZero() {
+		var y0, m0, y1, m1 int
+		if err := db.QueryRow("SELECT MIN(YEAR*12+MONTH-1), MAX(YEAR*12+MONTH-1) FROM DLY_FLOWS WHERE STATION_NUMBER = ?", station).Scan(&m0, &m1); err != nil {
+			return nil, fmt.Errorf("ReadHYDAT %s: station not found: %v", station, err)
+		}
+		y0, m0, y1, m1 = m0/12, m0%12+1, m1/12, m1%12+1
+		if start.IsZero() {
+			start = time.Date(y0, time.Month(m0), 1, 0, 0, 0, 0, time.UTC)
+		}
+		if end.IsZero() {
+			end = time.Date(y1, time.Month(m1+1), 0, 0, 0, 0, 0, time.UTC) // last day of month
+		}
+	}
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	if end.Before(start) {
+		return nil, fmt.Errorf("ReadHYDAT %s: end %s precedes start %s", station, end.Format("2006-01-02"), start.Format("2006-01-02"))
+	}
+
+	n := int(end.Sub(start).Hours()/24.) + 1
+	t := &Table{Time: make([]time.Time, n), Columns: []string{"Flow"}, Data: [][]float64{make([]float64, n)}, Flag: make([]Flag, n)}
+	for i := range t.Time {
+		t.Time[i] = start.AddDate(0, 0, i)
+		t.Data[0][i] = math.NaN()
+	}
+
+	cols := make([]string, 0, 2*31)
+	for d := 1; d <= 31; d++ {
+		cols = append(cols, fmt.Sprintf("FLOW%d", d), fmt.Sprintf("FLOW_SYMBOL%d", d))
+	}
+	rows, err := db.Query("SELECT YEAR, MONTH, NO_DAYS, "+strings.Join(cols, ", ")+" FROM DLY_FLOWS WHERE STATION_NUMBER = ? AND YEAR BETWEEN ? AND ? ORDER BY YEAR, MONTH", station, start.Year(), end.Year())
+	if err != nil {
+		return nil, fmt.Errorf("ReadHYDAT %s: %v", station, err)
+	}
+	defer rows.Close()
+
+	var yr, mo, nd int
+	q, s := make([]sql.NullFloat64, 31), make([]sql.NullString, 31)
+	dest := []interface{}{&yr, &mo, &nd}
+	for d := range q {
+		dest = append(dest, &q[d], &s[d])
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("ReadHYDAT %s: %v", station, err)
+		}
+		for d := 0; d < nd && d < 31; d++ {
+			i := int(time.Date(yr, time.Month(mo), d+1, 0, 0, 0, 0, time.UTC).Sub(start).Hours() / 24.)
+			if i < 0 || i >= n {
+				continue
+			}
+			if q[d].Valid {
+				t.Data[0][i] = q[d].Float64
+			}
+			if s[d].Valid {
+				t.Flag[i] = hydatFlag(s[d].String)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ReadHYDAT %s: %v", station, err)
+	}
+	return t, nil
+}
+
+// ReadHYDATStation reads the daily flows of a WSC station (see ReadHYDAT) and joins them with the
+// meteorological CSVs metfps (OWRC format, see ParseOWRC), returning a training-ready Table
+// dated by the flows, e.g., with columns "Flow", "Tx", "Tn", "Rf", "Sf", "Sm", "Pa".
+func ReadHYDATStation(db *sql.DB, station string, start, end time.Time, metfps ...string) (*Table, error) {
+	t, err := ReadHYDAT(db, station, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, fp := range metfps {
+		m, err := ReadOWRC(fp)
+		if err != nil {
+			return nil, err
+		}
+		t = t.Join(m)
+	}
+	return t, nil
+}
+
+// hydatFlag converts a HYDAT data symbol to a Flag
+func hydatFlag(s string) Flag {
+	switch strings.TrimSpace(s) {
+	case "":
+		return NoFlag
+	case "B":
+		return IceConditions
+	case "E":
+		return Estimate
+	case "A":
+		return Partial
+	}
+	return OtherFlag
+}
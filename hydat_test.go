@@ -0,0 +1,125 @@
+package goann
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hydatDriver is a database/sql driver serving a fixed DLY_FLOWS table of station 02EC018 for
+// January and February 2000: FLOWd = 100*month + d, except day 5 (NULL); day 3 has symbol "B"
+type hydatDriver struct{}
+type hydatConn struct{}
+type hydatStmt struct{ q string }
+type hydatRows struct {
+	ncol int
+	rows [][]driver.Value
+}
+
+func init() { sql.Register("hydat-fixture", hydatDriver{}) }
+
+func (hydatDriver) Open(string) (driver.Conn, error)         { return hydatConn{}, nil }
+func (hydatConn) Prepare(q string) (driver.Stmt, error)      { return hydatStmt{q}, nil }
+func (hydatConn) Close() error                               { return nil }
+func (hydatConn) Begin() (driver.Tx, error)                  { return nil, errors.ErrUnsupported }
+func (hydatStmt) Close() error                               { return nil }
+func (hydatStmt) NumInput() int                              { return -1 }
+func (hydatStmt) Exec([]driver.Value) (driver.Result, error) { return nil, errors.ErrUnsupported }
+
+func (s hydatStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if args[0] != "02EC018" {
+		return &hydatRows{ncol: 2}, nil
+	}
+	if strings.Contains(s.q, "MIN(") { // record extent, as YEAR*12+MONTH-1
+		return &hydatRows{ncol: 2, rows: [][]driver.Value{{int64(2000 * 12), int64(2000*12 + 1)}}}, nil
+	}
+	month := func(m, nd int64) []driver.Value {
+		r := []driver.Value{int64(2000), m, nd}
+		for d := int64(1); d <= 31; d++ {
+			var q, sym driver.Value
+			if d <= nd && d != 5 {
+				q = float64(100*m + d)
+			}
+			if d == 3 {
+				sym = "B"
+			}
+			r = append(r, q, sym)
+		}
+		return r
+	}
+	return &hydatRows{ncol: 3 + 2*31, rows: [][]driver.Value{month(1, 31), month(2, 29)}}, nil
+}
+
+func (r *hydatRows) Columns() []string { return make([]string, r.ncol) }
+func (r *hydatRows) Close() error      { return nil }
+func (r *hydatRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestReadHYDAT(t *testing.T) {
+	db, err := sql.Open("hydat-fixture", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tb, err := ReadHYDAT(db, "02EC018", time.Time{}, time.Time{}) // whole record
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, _ := tb.Column("Flow")
+	if tb.Len() != 60 || q[0] != 101. || q[31] != 201. || q[59] != 229. || !math.IsNaN(q[4]) {
+		t.Errorf("read %d days, Flow %v", tb.Len(), q)
+	}
+	if tb.Flag[2] != IceConditions || tb.Flag[3] != NoFlag {
+		t.Errorf("flags %v", tb.Flag[:4])
+	}
+
+	d := func(m, d int) time.Time { return time.Date(2000, time.Month(m), d, 0, 0, 0, 0, time.UTC) }
+	tb, err = ReadHYDAT(db, "02EC018", d(1, 30), d(3, 2)) // past the record: NaN
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, _ = tb.Column("Flow")
+	if tb.Len() != 33 || q[0] != 130. || q[2] != 201. || !math.IsNaN(q[32]) {
+		t.Errorf("read %d days, Flow %v", tb.Len(), q)
+	}
+	if _, err := ReadHYDAT(db, "02EC018", d(2, 1), d(1, 1)); err == nil {
+		t.Error("end preceding start accepted")
+	}
+	if _, err := ReadHYDAT(db, "02HB001", time.Time{}, time.Time{}); err == nil {
+		t.Error("missing station found")
+	}
+}
+
+func TestReadHYDATStation(t *testing.T) {
+	db, err := sql.Open("hydat-fixture", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	fp := filepath.Join(t.TempDir(), "met.csv")
+	if err := os.WriteFile(fp, []byte("Date,Flow,Tx\n2000-01-30,9,1.5\n2000-02-01,9,2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tb, err := ReadHYDATStation(db, "02EC018", time.Date(2000, 1, 30, 0, 0, 0, 0, time.UTC), time.Date(2000, 2, 1, 0, 0, 0, 0, time.UTC), fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := tb.Rows("Flow", "Tx") // HYDAT flows take precedence over the CSV's
+	if err != nil || len(tb.Columns) != 2 || x[0][0] != 130. || x[0][1] != 1.5 || !math.IsNaN(x[1][1]) || x[2][1] != 2. {
+		t.Errorf("columns %v, rows %v %v", tb.Columns, x, err)
+	}
+}
@@ -0,0 +1,304 @@
+package goann
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// IDXType is the data type of an IDX file (the format of the MNIST, Fashion-MNIST and EMNIST datasets)
+type IDXType byte
+
+// IDX data types, the third byte of the magic number
+const (
+	IDXUint8   IDXType = 0x08
+	IDXInt8    IDXType = 0x09
+	IDXInt16   IDXType = 0x0B
+	IDXInt32   IDXType = 0x0C
+	IDXFloat32 IDXType = 0x0D
+	IDXFloat64 IDXType = 0x0E
+)
+
+var idxSize = map[IDXType]int{IDXUint8: 1, IDXInt8: 1, IDXInt16: 2, IDXInt32: 4, IDXFloat32: 4, IDXFloat64: 8}
+
+func (t IDXType) String() string {
+	switch t {
+	case IDXUint8:
+		return "uint8"
+	case IDXInt8:
+		return "int8"
+	case IDXInt16:
+		return "int16"
+	case IDXInt32:
+		return "int32"
+	case IDXFloat32:
+		return "float32"
+	case IDXFloat64:
+		return "float64"
+	}
+	return fmt.Sprintf("IDXType(0x%02X)", byte(t))
+}
+
+// Tensor is an n-dimensional array of an IDX file, stored in row-major order in the slice
+// matching its Type (e.g., U8 for IDXUint8), all others being nil
+type Tensor struct {
+	Type IDXType
+	Dims []int // e.g., [images][rows][columns]
+	U8   []uint8
+	I8   []int8
+	I16  []int16
+	I32  []int32
+	F32  []float32
+	F64  []float64
+}
+
+// NewTensor returns a zero-valued tensor of the given type and dimensions
+func NewTensor(typ IDXType, dims ...int) (*Tensor, error) {
+	n, err := idxLen(typ, dims)
+	if err != nil {
+		return nil, err
+	}
+	t := &Tensor{Type: typ, Dims: dims}
+	switch typ {
+	case IDXUint8:
+		t.U8 = make([]uint8, n)
+	case IDXInt8:
+		t.I8 = make([]int8, n)
+	case IDXInt16:
+		t.I16 = make([]int16, n)
+	case IDXInt32:
+		t.I32 = make([]int32, n)
+	case IDXFloat32:
+		t.F32 = make([]float32, n)
+	case IDXFloat64:
+		t.F64 = make([]float64, n)
+	}
+	return t, nil
+}
+
+// Len returns the number of values
+func (t *Tensor) Len() int {
+	n := 1
+	for _, d := range t.Dims {
+		n *= d
+	}
+	return n
+}
+
+// Float64s returns every value, converted to float64
+func (t *Tensor) Float64s() []float64 {
+	o := make([]float64, t.Len())
+	for i := range o {
+		switch t.Type {
+		case IDXUint8:
+			o[i] = float64(t.U8[i])
+		case IDXInt8:
+			o[i] = float64(t.I8[i])
+		case IDXInt16:
+			o[i] = float64(t.I16[i])
+		case IDXInt32:
+			o[i] = float64(t.I32[i])
+		case IDXFloat32:
+			o[i] = float64(t.F32[i])
+		case IDXFloat64:
+			o[i] = t.F64[i]
+		}
+	}
+	return o
+}
+
+// Items returns the values of every index of the first dimension (e.g., every image, flattened
+// to rows*columns), converted to float64
+func (t *Tensor) Items() [][]float64 {
+	if len(t.Dims) == 0 {
+		return nil
+	}
+	if t.Dims[0] == 0 {
+		return [][]float64{}
+	}
+	x, n := t.Float64s(), t.Len()/t.Dims[0]
+	o := make([][]float64, t.Dims[0])
+	for i := range o {
+		o[i] = x[i*n : (i+1)*n]
+	}
+	return o
+}
+
+// ReadIDX reads an IDX file, gzip-compressed (e.g., train-images-idx3-ubyte.gz) or not
+func ReadIDX(fp string) (*Tensor, error) {
+	f, err := os.Open(fp)
+	if err != nil {
+		return nil, fmt.Errorf("ReadIDX: %v", err)
+	}
+	defer f.Close()
+	t, err := ParseIDX(f)
+	if err != nil {
+		return nil, fmt.Errorf("ReadIDX %s: %v", fp, err)
+	}
+	return t, nil
+}
+
+// ParseIDX reads IDX data, decompressing gzip streams: a magic number (two zero bytes, the data type
+// and the number of dimensions), the big-endian 32-bit length of every dimension, then the
+// big-endian values
+func ParseIDX(r io.Reader) (*Tensor, error) {
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(2); err == nil && b[0] == 0x1f && b[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		br = bufio.NewReader(gz)
+	}
+
+	var magic [4]byte
+	if _, err := io.ReadFull(br, magic[:]); err != nil {
+		return nil, fmt.Errorf("magic number read fail: %v", err)
+	}
+	if magic[0] != 0 || magic[1] != 0 {
+		return nil, fmt.Errorf("not an IDX file (magic number %X)", magic)
+	}
+	typ := IDXType(magic[2])
+	if _, ok := idxSize[typ]; !ok {
+		return nil, fmt.Errorf("unknown data type 0x%02X", magic[2])
+	}
+	dims := make([]int, magic[3])
+	for k := range dims {
+		var d uint32
+		if err := binary.Read(br, binary.BigEndian, &d); err != nil {
+			return nil, fmt.Errorf("dimension %d read fail: %v", k, err)
+		}
+		dims[k] = int(d)
+	}
+	n, err := idxLen(typ, dims)
+	if err != nil {
+		return nil, err
+	}
+	// read before allocating, such that a corrupt header cannot claim an arbitrary allocation
+	b, err := io.ReadAll(io.LimitReader(br, int64(n*idxSize[typ])))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < n*idxSize[typ] {
+		return nil, fmt.Errorf("truncated data: expecting %d %s values %v", n, typ, dims)
+	}
+	t, _ := NewTensor(typ, dims...)
+	switch typ {
+	case IDXUint8:
+		copy(t.U8, b)
+	case IDXInt8:
+		for i, c := range b {
+			t.I8[i] = int8(c)
+		}
+	case IDXInt16:
+		for i := range t.I16 {
+			t.I16[i] = int16(binary.BigEndian.Uint16(b[2*i:]))
+		}
+	case IDXInt32:
+		for i := range t.I32 {
+			t.I32[i] = int32(binary.BigEndian.Uint32(b[4*i:]))
+		}
+	case IDXFloat32:
+		for i := range t.F32 {
+			t.F32[i] = math.Float32frombits(binary.BigEndian.Uint32(b[4*i:]))
+		}
+	case IDXFloat64:
+		for i := range t.F64 {
+			t.F64[i] = math.Float64frombits(binary.BigEndian.Uint64(b[8*i:]))
+		}
+	}
+	return t, nil
+}
+
+// WriteIDX writes a tensor to an IDX file, gzip-compressed when fp ends with ".gz"
+func WriteIDX(fp string, t *Tensor) error {
+	f, err := os.Create(fp)
+	if err != nil {
+		return fmt.Errorf("WriteIDX: %v", err)
+	}
+	var w io.Writer = f
+	var gz *gzip.Writer
+	if strings.HasSuffix(fp, ".gz") {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+	bw := bufio.NewWriter(w)
+	err = EncodeIDX(bw, t)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("WriteIDX %s: %v", fp, err)
+	}
+	return nil
+}
+
+// EncodeIDX writes a tensor in the IDX format (see ParseIDX)
+func EncodeIDX(w io.Writer, t *Tensor) error {
+	n, err := idxLen(t.Type, t.Dims)
+	if err != nil {
+		return err
+	}
+	var data interface{}
+	var m int
+	switch t.Type {
+	case IDXUint8:
+		data, m = t.U8, len(t.U8)
+	case IDXInt8:
+		data, m = t.I8, len(t.I8)
+	case IDXInt16:
+		data, m = t.I16, len(t.I16)
+	case IDXInt32:
+		data, m = t.I32, len(t.I32)
+	case IDXFloat32:
+		data, m = t.F32, len(t.F32)
+	case IDXFloat64:
+		data, m = t.F64, len(t.F64)
+	}
+	if m != n {
+		return fmt.Errorf("tensor %v holds %d %s values, expecting %d", t.Dims, m, t.Type, n)
+	}
+	if _, err := w.Write([]byte{0, 0, byte(t.Type), byte(len(t.Dims))}); err != nil {
+		return err
+	}
+	for _, d := range t.Dims {
+		if err := binary.Write(w, binary.BigEndian, uint32(d)); err != nil {
+			return err
+		}
+	}
+	return binary.Write(w, binary.BigEndian, data)
+}
+
+// idxLen checks the type and dimensions of a tensor, returning its number of values
+func idxLen(typ IDXType, dims []int) (int, error) {
+	sz, ok := idxSize[typ]
+	if !ok {
+		return 0, fmt.Errorf("unknown data type 0x%02X", byte(typ))
+	}
+	if len(dims) > 255 {
+		return 0, fmt.Errorf("%d dimensions, at most 255 allowed", len(dims))
+	}
+	n := 1
+	for _, d := range dims {
+		if d < 0 || int64(d) > math.MaxUint32 {
+			return 0, fmt.Errorf("invalid dimension length %d", d)
+		}
+		if d > 0 && int64(n)*int64(sz) > (1<<40)/int64(d) {
+			return 0, fmt.Errorf("tensor %v too large", dims)
+		}
+		n *= d
+	}
+	return n, nil
+}
@@ -0,0 +1,90 @@
+package goann
+
+import (
+	"bytes"
+	"compress/gzip"
+	"path/filepath"
+	"testing"
+)
+
+func TestIDXRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	for _, typ := range []IDXType{IDXUint8, IDXInt8, IDXInt16, IDXInt32, IDXFloat32, IDXFloat64} {
+		x, err := NewTensor(typ, 2, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch typ {
+		case IDXUint8:
+			x.U8[5] = 200
+		case IDXInt8:
+			x.I8[5] = -100
+		case IDXInt16:
+			x.I16[5] = -30000
+		case IDXInt32:
+			x.I32[5] = -2000000000
+		case IDXFloat32:
+			x.F32[5] = -1.5
+		case IDXFloat64:
+			x.F64[5] = 1e300
+		}
+		for _, fn := range []string{"x.idx", "x.idx.gz"} {
+			fp := filepath.Join(dir, fn)
+			if err := WriteIDX(fp, x); err != nil {
+				t.Fatal(err)
+			}
+			y, err := ReadIDX(fp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if y.Type != typ || len(y.Dims) != 2 || y.Dims[0] != 2 || y.Dims[1] != 3 {
+				t.Fatalf("%s %s: read %s %v", typ, fn, y.Type, y.Dims)
+			}
+			a, b := x.Float64s(), y.Float64s()
+			for i := range a {
+				if a[i] != b[i] {
+					t.Fatalf("%s %s: value %d read %g, written %g", typ, fn, i, b[i], a[i])
+				}
+			}
+		}
+	}
+}
+
+func TestParseIDX(t *testing.T) {
+	lbl := []byte{0, 0, 8, 1, 0, 0, 0, 3, 7, 2, 1} // MNIST-style labels: magic 0x00000801, 3 values
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write(lbl)
+	w.Close()
+	for _, b := range [][]byte{lbl, gz.Bytes()} {
+		x, err := ParseIDX(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x.Type != IDXUint8 || len(x.U8) != 3 || x.U8[0] != 7 || x.U8[2] != 1 {
+			t.Fatalf("read %s %v", x.Type, x.U8)
+		}
+	}
+	for name, b := range map[string][]byte{
+		"truncated": lbl[:10],
+		"huge":      {0, 0, 8, 1, 0xff, 0xff, 0xff, 0xff},
+		"type":      {0, 0, 7, 1, 0, 0, 0, 1, 1},
+		"magic":     {1, 0, 8, 1, 0, 0, 0, 1, 1},
+	} {
+		if _, err := ParseIDX(bytes.NewReader(b)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestTensorItems(t *testing.T) {
+	x, _ := NewTensor(IDXUint8, 2, 2, 2)
+	x.U8[7] = 9
+	if it := x.Items(); len(it) != 2 || len(it[1]) != 4 || it[1][3] != 9 {
+		t.Errorf("items %v", it)
+	}
+	empty, _ := NewTensor(IDXUint8, 0, 28, 28)
+	if it := empty.Items(); it == nil || len(it) != 0 {
+		t.Errorf("items of an empty tensor %v", it)
+	}
+}
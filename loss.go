@@ -0,0 +1,139 @@
+package goann
+
+import "math"
+
+// Loss is a loss layer: the loss of a prediction given its label, and the derivative
+// of the loss w.r.t. the prediction (the bottom diff). Missing (NaN) labels are excluded
+// from both the loss and its derivative.
+type Loss interface {
+	Loss(pred, label []float64) float64
+	Diff(pred, label []float64) []float64
+}
+
+// MSE squared-error loss
+type MSE struct{}
+
+func (MSE) Loss(pred, label []float64) float64 {
+	s := 0.
+	for k, y := range label {
+		if math.IsNaN(y) {
+			continue
+		}
+		e := pred[k] - y
+		s += e * e
+	}
+	return s
+}
+
+func (MSE) Diff(pred, label []float64) []float64 {
+	o := make([]float64, len(pred))
+	for k, y := range label {
+		if math.IsNaN(y) {
+			continue
+		}
+		o[k] = 2. * (pred[k] - y)
+	}
+	return o
+}
+
+// halfMSE squared-error loss (as MSE) back-propagating e = pred-label, the gradient of half the squared
+// error: the step size LSTMlayers.Train was tuned to, kept such that existing learning rates still hold
+type halfMSE struct{}
+
+func (halfMSE) Loss(pred, label []float64) float64 { return MSE{}.Loss(pred, label) }
+
+func (halfMSE) Diff(pred, label []float64) []float64 {
+	o := MSE{}.Diff(pred, label)
+	for k := range o {
+		o[k] *= .5
+	}
+	return o
+}
+
+// NSE squared-error loss weighted by the observed flow variability of the basin,
+// (pred-label)^2 / (Std+Eps)^2, i.e., NSE* of Kratzert et.al., 2019
+type NSE struct {
+	Std, Eps float64 // standard deviation of the observations (of the basin); Eps stabilizer (~.1)
+}
+
+func (l NSE) Loss(pred, label []float64) float64 {
+	d := l.Std + l.Eps
+	return MSE{}.Loss(pred, label) / d / d
+}
+
+func (l NSE) Diff(pred, label []float64) []float64 {
+	d := l.Std + l.Eps
+	o := MSE{}.Diff(pred, label)
+	for k := range o {
+		o[k] /= d * d
+	}
+	return o
+}
+
+// Huber loss: quadratic for errors smaller than Delta, linear beyond
+type Huber struct{ Delta float64 }
+
+func (l Huber) Loss(pred, label []float64) float64 {
+	s := 0.
+	for k, y := range label {
+		if math.IsNaN(y) {
+			continue
+		}
+		e := math.Abs(pred[k] - y)
+		if e <= l.Delta {
+			s += .5 * e * e
+		} else {
+			s += l.Delta * (e - .5*l.Delta)
+		}
+	}
+	return s
+}
+
+func (l Huber) Diff(pred, label []float64) []float64 {
+	o := make([]float64, len(pred))
+	for k, y := range label {
+		if math.IsNaN(y) {
+			continue
+		}
+		e := pred[k] - y
+		switch {
+		case e > l.Delta:
+			o[k] = l.Delta
+		case e < -l.Delta:
+			o[k] = -l.Delta
+		default:
+			o[k] = e
+		}
+	}
+	return o
+}
+
+// GaussianNLL negative log-likelihood of a Gaussian. For n labels, the prediction holds
+// 2n elements: n means followed by n log-variances.
+type GaussianNLL struct{}
+
+func (GaussianNLL) Loss(pred, label []float64) float64 {
+	n, s := len(label), 0.
+	for k, y := range label {
+		if math.IsNaN(y) {
+			continue
+		}
+		e := y - pred[k]
+		s += .5 * (math.Log(2.*math.Pi) + pred[n+k] + e*e*math.Exp(-pred[n+k]))
+	}
+	return s
+}
+
+func (GaussianNLL) Diff(pred, label []float64) []float64 {
+	n, o := len(label), make([]float64, len(pred))
+	for k, y := range label {
+		if math.IsNaN(y) {
+			continue
+		}
+		e := y - pred[k]
+		iv := math.Exp(-pred[n+k])
+		o[k] = -e * iv
+		o[n+k] = .5 * (1. - e*e*iv)
+	}
+	return o
+}
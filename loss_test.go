@@ -0,0 +1,54 @@
+package goann
+
+import (
+	"math"
+	"testing"
+)
+
+// diffCheck compares the bottom diff of a loss layer against central differences of its loss
+func diffCheck(t *testing.T, name string, l Loss, pred, label []float64) {
+	t.Helper()
+	const h = 1e-6
+	d := l.Diff(pred, label)
+	for i, p := range pred {
+		pred[i] = p + h
+		lp := l.Loss(pred, label)
+		pred[i] = p - h
+		lm := l.Loss(pred, label)
+		pred[i] = p
+		if num := (lp - lm) / 2. / h; math.Abs(num-d[i]) > 1e-5*math.Max(1., math.Abs(num)) {
+			t.Errorf("%s: diff [%d]: numerical %g, analytical %g", name, i, num, d[i])
+		}
+	}
+}
+
+func TestLossDiff(t *testing.T) {
+	pred, label := []float64{.3, -1.2, .8}, []float64{.1, -.4, math.NaN()}
+	for _, c := range []struct {
+		name string
+		l    Loss
+	}{
+		{"MSE", MSE{}},
+		{"NSE", NSE{Std: .8, Eps: .1}},
+		{"Huber", Huber{Delta: .5}},
+	} {
+		diffCheck(t, c.name, c.l, pred, label)
+		if d := c.l.Diff(pred, label); d[2] != 0. {
+			t.Errorf("%s: missing label has diff %g", c.name, d[2])
+		}
+	}
+	diffCheck(t, "GaussianNLL", GaussianNLL{}, []float64{.3, -1.2, .2, -.5}, []float64{.1, .4})
+}
+
+func TestHalfMSE(t *testing.T) {
+	pred, label := []float64{.3, -1.2}, []float64{.1, -.4}
+	if a, b := (halfMSE{}).Loss(pred, label), (MSE{}).Loss(pred, label); a != b {
+		t.Errorf("loss %g, expecting that of MSE %g", a, b)
+	}
+	d, e := (halfMSE{}).Diff(pred, label), (MSE{}).Diff(pred, label)
+	for k := range d {
+		if math.Abs(d[k]-(pred[k]-label[k])) > 1e-15 || math.Abs(2.*d[k]-e[k]) > 1e-15 {
+			t.Errorf("diff [%d] %g, expecting pred-label %g", k, d[k], pred[k]-label[k])
+		}
+	}
+}
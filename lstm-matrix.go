@@ -1,8 +1,9 @@
 package goann
 
-// LTSM matrix formulation modified from https://github.com/nicodjimenez/lstm
+// LSTM matrix formulation modified from https://github.com/nicodjimenez/lstm
 
 import (
+	"fmt"
 	"math"
 	"math/rand"
 )
@@ -35,103 +36,68 @@ func dot(a, b []float64) float64 {
 	return s
 }
 
-func hstack(a, b []float64) []float64 {
-	s := make([]float64, len(a)+len(b))
-	for i, aa := range a {
-		s[i] = aa
-	}
-	for i, bb := range b {
-		s[i+len(a)] = bb
-	}
-	return s
-}
-
-func transpose(a [][]float64) [][]float64 {
-	o := make([][]float64, len(a[0]))
-	for j := 0; j < len(a[0]); j++ {
-		o[j] = make([]float64, len(a))
-		for i := 0; i < len(a); i++ {
-			o[j][i] = a[i][j]
-		}
-	}
-	return o
-}
-
-func outer(a, b []float64) [][]float64 {
-	o := make([][]float64, len(a))
-	for i, aa := range a {
-		o[i] = make([]float64, len(b))
-		for j, bb := range b {
-			o[i][j] = aa * bb
-		}
+// rows returns nr x nc row views into the contiguous (row-major) array a
+func rows(a []float64, nr, nc int) [][]float64 {
+	o := make([][]float64, nr)
+	for i := 0; i < nr; i++ {
+		o[i] = a[i*nc : (i+1)*nc : (i+1)*nc]
 	}
 	return o
 }
 
-// LTSMparam modified from https://github.com/nicodjimenez/lstm
-type LTSMparam struct {
-	wg, wi, wf, wo, wgDiff, wiDiff, wfDiff, woDiff [][]float64
-	bg, bi, bf, bo, bgDiff, biDiff, bfDiff, boDiff []float64
+// LSTMparam modified from https://github.com/nicodjimenez/lstm
+// Weights, biases and their diffs are each held in a single flat buffer (gates g, i, f, o
+// stacked) such that updates and resets are single passes over contiguous memory.
+type LSTMparam struct {
+	wg, wi, wf, wo, wgDiff, wiDiff, wfDiff, woDiff [][]float64 // row views into w and wDiff
+	bg, bi, bf, bo, bgDiff, biDiff, bfDiff, boDiff []float64   // views into b and bDiff
+	w, wDiff, b, bDiff                             []float64
 	mem_cell_ct, x_dim                             int
 }
 
-func NewLTSMparam(mem_cell_ct, x_dim int) LTSMparam {
+func NewLSTMparam(mem_cell_ct, x_dim int) LSTMparam {
 	concat_len := x_dim + mem_cell_ct
-	return LTSMparam{
+	nw := mem_cell_ct * concat_len
+	l := LSTMparam{
 		// weight matrices
-		wg: randArr(-0.1, 0.1, mem_cell_ct, concat_len),
-		wi: randArr(-0.1, 0.1, mem_cell_ct, concat_len),
-		wf: randArr(-0.1, 0.1, mem_cell_ct, concat_len),
-		wo: randArr(-0.1, 0.1, mem_cell_ct, concat_len),
+		w: make([]float64, 4*nw),
 		// bias terms
-		bg: randArr(-0.1, 0.1, 1, mem_cell_ct)[0],
-		bi: randArr(-0.1, 0.1, 1, mem_cell_ct)[0],
-		bf: randArr(-0.1, 0.1, 1, mem_cell_ct)[0],
-		bo: randArr(-0.1, 0.1, 1, mem_cell_ct)[0],
+		b: make([]float64, 4*mem_cell_ct),
 		// diffs (derivative of loss function w.r.t. all parameters)
-		wgDiff: zeros(mem_cell_ct, concat_len),
-		wiDiff: zeros(mem_cell_ct, concat_len),
-		wfDiff: zeros(mem_cell_ct, concat_len),
-		woDiff: zeros(mem_cell_ct, concat_len),
-		bgDiff: zeros(1, mem_cell_ct)[0],
-		biDiff: zeros(1, mem_cell_ct)[0],
-		bfDiff: zeros(1, mem_cell_ct)[0],
-		boDiff: zeros(1, mem_cell_ct)[0],
+		wDiff: make([]float64, 4*nw),
+		bDiff: make([]float64, 4*mem_cell_ct),
 
 		mem_cell_ct: mem_cell_ct,
 		x_dim:       x_dim,
 	}
+	for i := range l.w {
+		l.w[i] = rand.Float64()*0.2 - 0.1
+	}
+	for i := range l.b {
+		l.b[i] = rand.Float64()*0.2 - 0.1
+	}
+	l.wg, l.wi, l.wf, l.wo = rows(l.w[:nw], mem_cell_ct, concat_len), rows(l.w[nw:2*nw], mem_cell_ct, concat_len), rows(l.w[2*nw:3*nw], mem_cell_ct, concat_len), rows(l.w[3*nw:], mem_cell_ct, concat_len)
+	l.wgDiff, l.wiDiff, l.wfDiff, l.woDiff = rows(l.wDiff[:nw], mem_cell_ct, concat_len), rows(l.wDiff[nw:2*nw], mem_cell_ct, concat_len), rows(l.wDiff[2*nw:3*nw], mem_cell_ct, concat_len), rows(l.wDiff[3*nw:], mem_cell_ct, concat_len)
+	m := mem_cell_ct
+	l.bg, l.bi, l.bf, l.bo = l.b[:m:m], l.b[m:2*m:2*m], l.b[2*m:3*m:3*m], l.b[3*m:]
+	l.bgDiff, l.biDiff, l.bfDiff, l.boDiff = l.bDiff[:m:m], l.bDiff[m:2*m:2*m], l.bDiff[2*m:3*m:3*m], l.bDiff[3*m:]
+	return l
 }
 
-func (l *LTSMparam) ApplyDiff(lr float64) {
-	concat_len := l.x_dim + l.mem_cell_ct
-	for i := 0; i < l.mem_cell_ct; i++ {
-		for j := 0; j < concat_len; j++ {
-			l.wg[i][j] -= lr * l.wgDiff[i][j]
-			l.wi[i][j] -= lr * l.wiDiff[i][j]
-			l.wf[i][j] -= lr * l.wfDiff[i][j]
-			l.wo[i][j] -= lr * l.woDiff[i][j]
-		}
-		l.bg[i] -= lr * l.bgDiff[i]
-		l.bi[i] -= lr * l.biDiff[i]
-		l.bf[i] -= lr * l.bfDiff[i]
-		l.bo[i] -= lr * l.boDiff[i]
-	}
-
-	// reset diffs to zero
-	l.wgDiff = zeros(l.mem_cell_ct, concat_len)
-	l.wiDiff = zeros(l.mem_cell_ct, concat_len)
-	l.wfDiff = zeros(l.mem_cell_ct, concat_len)
-	l.woDiff = zeros(l.mem_cell_ct, concat_len)
-	l.bgDiff = zeros(1, l.mem_cell_ct)[0]
-	l.biDiff = zeros(1, l.mem_cell_ct)[0]
-	l.bfDiff = zeros(1, l.mem_cell_ct)[0]
-	l.boDiff = zeros(1, l.mem_cell_ct)[0]
+func (l *LSTMparam) ApplyDiff(lr float64) {
+	for i, d := range l.wDiff {
+		l.w[i] -= lr * d
+		l.wDiff[i] = 0. // reset diffs to zero (in place, nodes share these arrays)
+	}
+	for i, d := range l.bDiff {
+		l.b[i] -= lr * d
+		l.bDiff[i] = 0.
+	}
 }
 
 type LSTMstate struct{ g, i, f, o, s, H, bottomDiffh, bottomDiffs []float64 }
 
-func NewLTSMstate(mem_cell_ct int) LSTMstate {
+func NewLSTMstate(mem_cell_ct int) LSTMstate {
 	return LSTMstate{
 		g:           zeros(1, mem_cell_ct)[0],
 		i:           zeros(1, mem_cell_ct)[0],
@@ -146,27 +112,44 @@ func NewLTSMstate(mem_cell_ct int) LSTMstate {
 
 type LSTMnode struct {
 	State            LSTMstate
-	param            LTSMparam
+	param            LSTMparam
 	xc, sPrev, hPrev []float64 // xc: non-recurrent input concatenated with recurrent input
+
+	// preallocated work arrays for back-propagation
+	ds, diInput, dfInput, doInput, dgInput, dxc, zero []float64
 }
 
-func NewLSTMnode(ls LSTMstate, lp LTSMparam) LSTMnode {
-	return LSTMnode{State: ls, param: lp}
+func NewLSTMnode(ls LSTMstate, lp LSTMparam) LSTMnode {
+	m, concat_len := lp.mem_cell_ct, lp.x_dim+lp.mem_cell_ct
+	return LSTMnode{
+		State:   ls,
+		param:   lp,
+		xc:      make([]float64, concat_len),
+		ds:      make([]float64, m),
+		diInput: make([]float64, m),
+		dfInput: make([]float64, m),
+		doInput: make([]float64, m),
+		dgInput: make([]float64, m),
+		dxc:     make([]float64, concat_len),
+		zero:    make([]float64, m),
+	}
 }
 
 func (ln *LSTMnode) bottomDataIs(x, sPrev, hPrev []float64) {
 	// if this is the first lstm node in the network
 	if sPrev == nil {
-		sPrev = zeros(1, ln.param.mem_cell_ct)[0]
+		sPrev = ln.zero
 	}
 	if hPrev == nil {
-		hPrev = zeros(1, ln.param.mem_cell_ct)[0]
+		hPrev = ln.zero
 	}
 	// save data for use in backprop
 	ln.sPrev = sPrev
 	ln.hPrev = hPrev
 
-	ln.xc = hstack(x, hPrev) // concatenate x(t) and h(t-1)
+	// concatenate x(t) and h(t-1)
+	copy(ln.xc, x)
+	copy(ln.xc[ln.param.x_dim:], hPrev)
 	for i := 0; i < ln.param.mem_cell_ct; i++ {
 		ln.State.g[i] = math.Tanh(dot(ln.param.wg[i], ln.xc) + ln.param.bg[i])
 		ln.State.i[i] = sigmoid(dot(ln.param.wi[i], ln.xc) + ln.param.bi[i])
@@ -178,115 +161,221 @@ func (ln *LSTMnode) bottomDataIs(x, sPrev, hPrev []float64) {
 }
 
 func (ln *LSTMnode) topDiffIs(topDiffh, topDiffs []float64) {
-	concat_len := ln.param.x_dim + ln.param.mem_cell_ct
 	// notice that top_diff_s is carried along the constant error carousel
-	ds, doInput, diInput, dgInput, dfInput := make([]float64, ln.param.mem_cell_ct), make([]float64, ln.param.mem_cell_ct), make([]float64, ln.param.mem_cell_ct), make([]float64, ln.param.mem_cell_ct), make([]float64, ln.param.mem_cell_ct)
 	for i := 0; i < ln.param.mem_cell_ct; i++ {
-		ds[i] = ln.State.o[i]*topDiffh[i] + topDiffs[i]
+		ln.ds[i] = ln.State.o[i]*topDiffh[i] + topDiffs[i]
 		do := ln.State.s[i] * topDiffh[i]
-		di := ln.State.g[i] * ds[i]
-		dg := ln.State.i[i] * ds[i]
-		df := ln.sPrev[i] * ds[i]
+		di := ln.State.g[i] * ln.ds[i]
+		dg := ln.State.i[i] * ln.ds[i]
+		df := ln.sPrev[i] * ln.ds[i]
 
 		// diffs w.r.t. vector inside sigma / tanh function
-		diInput[i] = sigmoidPrime(ln.State.i[i]) * di
-		dfInput[i] = sigmoidPrime(ln.State.f[i]) * df
-		doInput[i] = sigmoidPrime(ln.State.o[i]) * do
-		dgInput[i] = tanhPrime(ln.State.g[i]) * dg
-
-		diO, dfO, doO, dgO := outer(diInput, ln.xc), outer(dfInput, ln.xc), outer(doInput, ln.xc), outer(dgInput, ln.xc)
-		for j := 0; j < concat_len; j++ {
-			// diffs w.r.t. inputs
-			ln.param.wiDiff[i][j] += diO[i][j]
-			ln.param.wfDiff[i][j] += dfO[i][j]
-			ln.param.woDiff[i][j] += doO[i][j]
-			ln.param.wgDiff[i][j] += dgO[i][j]
-		}
-		ln.param.biDiff[i] += diInput[i]
-		ln.param.bfDiff[i] += dfInput[i]
-		ln.param.boDiff[i] += doInput[i]
-		ln.param.bgDiff[i] += dgInput[i]
+		// (gates are stored activated, derivatives are thus taken w.r.t. their values)
+		ln.diInput[i] = ln.State.i[i] * (1. - ln.State.i[i]) * di
+		ln.dfInput[i] = ln.State.f[i] * (1. - ln.State.f[i]) * df
+		ln.doInput[i] = ln.State.o[i] * (1. - ln.State.o[i]) * do
+		ln.dgInput[i] = (1. - ln.State.g[i]*ln.State.g[i]) * dg
 	}
 
-	// compute bottom diff
-	dxc := zeros(1, concat_len)[0]
-	wiT, wfT, woT, wgT := transpose(ln.param.wi), transpose(ln.param.wf), transpose(ln.param.wo), transpose(ln.param.wg)
-	for i := 0; i < concat_len; i++ {
-		dxc[i] += dot(wiT[i], diInput)
-		dxc[i] += dot(wfT[i], dfInput)
-		dxc[i] += dot(woT[i], doInput)
-		dxc[i] += dot(wgT[i], dgInput)
+	// diffs w.r.t. inputs (outer products accumulated in place) and bottom diff (W^T d, accumulated row-wise: no transposes)
+	for j := range ln.dxc {
+		ln.dxc[j] = 0.
+	}
+	for i := 0; i < ln.param.mem_cell_ct; i++ {
+		di, df, do, dg := ln.diInput[i], ln.dfInput[i], ln.doInput[i], ln.dgInput[i]
+		wi, wf, wo, wg := ln.param.wi[i], ln.param.wf[i], ln.param.wo[i], ln.param.wg[i]
+		wiDiff, wfDiff, woDiff, wgDiff := ln.param.wiDiff[i], ln.param.wfDiff[i], ln.param.woDiff[i], ln.param.wgDiff[i]
+		for j, x := range ln.xc {
+			wiDiff[j] += di * x
+			wfDiff[j] += df * x
+			woDiff[j] += do * x
+			wgDiff[j] += dg * x
+			ln.dxc[j] += wi[j]*di + wf[j]*df + wo[j]*do + wg[j]*dg
+		}
+		ln.param.biDiff[i] += di
+		ln.param.bfDiff[i] += df
+		ln.param.boDiff[i] += do
+		ln.param.bgDiff[i] += dg
 	}
 
 	// save bottom diffs
 	for i := 0; i < ln.param.mem_cell_ct; i++ {
-		ln.State.bottomDiffs[i] = ds[i] * ln.State.f[i]
-		ln.State.bottomDiffh[i] = dxc[i+ln.param.x_dim]
+		ln.State.bottomDiffs[i] = ln.ds[i] * ln.State.f[i]
+		ln.State.bottomDiffh[i] = ln.dxc[i+ln.param.x_dim]
 	}
 }
 
 type LSTMnetwork struct {
-	param    LTSMparam
+	param    LSTMparam
 	NodeList []LSTMnode
-	xList    [][]float64 // input sequence
+	head     Dense        // output layer
+	xList    [][]float64  // input sequence
+	hc       []denseCache // output layer forward passes of the input sequence
+	s, h     []float64    // running state
+	pnode    *LSTMnode    // node used for prediction
+}
+
+// NewLSTMnetwork lp: lstm parameters; ny: number of outputs (targets) of the dense output layer having activation act
+func NewLSTMnetwork(lp LSTMparam, ny int, act Activation) LSTMnetwork {
+	return LSTMnetwork{param: lp, head: newDense(lp.mem_cell_ct, ny, act), NodeList: []LSTMnode{}, xList: [][]float64{}}
 }
 
-func NewLSTMnetwork(lp LTSMparam) LSTMnetwork {
-	return LSTMnetwork{param: lp, NodeList: []LSTMnode{}, xList: [][]float64{}}
+// ApplyDiff updates the lstm parameters and the output layer, then resets diffs to zero
+func (lw *LSTMnetwork) ApplyDiff(lr float64) {
+	ApplyDiff(lw.Params(), lr)
 }
 
-func (lw *LSTMnetwork) YListIs(yList []float64) float64 {
+// Params returns the lstm parameters (weights, biases) and those of the output layer
+func (lw *LSTMnetwork) Params() []Param {
+	return []Param{{W: lw.param.w, D: lw.param.wDiff}, {W: lw.param.b, D: lw.param.bDiff}, lw.head.params()}
+}
+
+// Reset returns the state to rest
+func (lw *LSTMnetwork) Reset() {
+	lw.s, lw.h = nil, nil
+}
+
+// Step advances the (trained) network one timestep, returning the output layer prediction.
+// Weights, diffs and the training sequence (NodeList) are left untouched.
+func (lw *LSTMnetwork) Step(x []float64) []float64 {
+	if lw.pnode == nil {
+		ln := NewLSTMnode(NewLSTMstate(lw.param.mem_cell_ct), lw.param)
+		lw.pnode = &ln
+	}
+	if lw.s == nil {
+		lw.s, lw.h = zeros(1, lw.param.mem_cell_ct)[0], zeros(1, lw.param.mem_cell_ct)[0]
+	}
+	lw.pnode.bottomDataIs(x, lw.s, lw.h)
+	copy(lw.s, lw.pnode.State.s)
+	copy(lw.h, lw.pnode.State.H)
+	return lw.head.predict(lw.h)
+}
+
+// Predict runs the (trained) network over an input sequence, starting from rest. The state is
+// left at the end of the sequence, such that it can be snapshot (State) and advanced using Step.
+func (lw *LSTMnetwork) Predict(xList [][]float64) [][]float64 {
+	lw.Reset()
+	o := make([][]float64, len(xList))
+	for i, x := range xList {
+		o[i] = lw.Step(x)
+	}
+	return o
+}
+
+// Hidden returns a copy of the hidden state after the last call to Step
+func (lw *LSTMnetwork) Hidden() []float64 {
+	if lw.h == nil {
+		return zeros(1, lw.param.mem_cell_ct)[0]
+	}
+	return append([]float64(nil), lw.h...)
+}
+
+// headForward feeds the hidden state of every node in the input sequence to the output layer
+func (lw *LSTMnetwork) headForward() [][]float64 {
+	pred := make([][]float64, len(lw.xList))
+	lw.hc = make([]denseCache, len(lw.xList))
+	for idx := range lw.xList {
+		pred[idx] = lw.head.train(&lw.hc[idx], lw.NodeList[idx].State.H)
+	}
+	return pred
+}
+
+func (lw *LSTMnetwork) YListIs(yList [][]float64, lossLayer Loss) float64 {
+	if len(yList) != len(lw.xList) {
+		panic("lw.yListIs ERROR 1")
+	}
+	return lw.yListIs(yList, nil, lossLayer)
+}
+
+// YListIsWeighted as YListIs, where the loss of every timestep is scaled by its weight (nil: 1).
+// Returns an error when the target, input and weight lengths differ.
+func (lw *LSTMnetwork) YListIsWeighted(yList [][]float64, wts []float64, lossLayer Loss) (float64, error) {
+	if len(yList) != len(lw.xList) {
+		return 0., fmt.Errorf("YListIsWeighted: input (%d) and target (%d) lengths differ", len(lw.xList), len(yList))
+	}
+	if wts != nil && len(wts) != len(yList) {
+		return 0., fmt.Errorf("YListIsWeighted: target (%d) and weight (%d) lengths differ", len(yList), len(wts))
+	}
+	return lw.yListIs(yList, wts, lossLayer), nil
+}
+
+func (lw *LSTMnetwork) yListIs(yList [][]float64, wts []float64, lossLayer Loss) float64 {
 	/*
 	   Updates diffs by setting target sequence
 	   with corresponding loss layer.
 	   Will *NOT* update parameters. To update parameters,
-	   call self.lstm_param.apply_diff()
+	   call lw.ApplyDiff()
 	*/
-	if len(yList) != len(lw.xList) {
-		panic("lw.yListIs ERROR 1")
+	pred := lw.headForward()
+	loss, dy := 0., make([][]float64, len(yList))
+	for idx, y := range yList {
+		w := weightAt(wts, idx)
+		loss += w * lossLayer.Loss(pred[idx], y)
+		dy[idx] = lossLayer.Diff(pred[idx], y)
+		for k := range dy[idx] {
+			dy[idx][k] *= w
+		}
 	}
-	idx := len(lw.xList) - 1
-	// first node only gets diffs from label ...
-	lossLayer := func(pred []float64, label float64) float64 {
-		f := pred[0] * label
-		return f * f // Computes square loss with first element of hidden layer array.
-	}
-	bottomDiffLayer := func(pred []float64, label float64) []float64 {
-		o := make([]float64, len(pred))
-		o[0] = 2 * pred[0] * label
-		return o
-	}
-	loss := lossLayer(lw.NodeList[idx].State.H, yList[idx])
-	diffh := bottomDiffLayer(lw.NodeList[idx].State.H, yList[idx])
-	// here s is not affecting loss due to h(t+1), hence we set equal to zero
-	diffs := zeros(1, lw.param.mem_cell_ct)[0]
-	lw.NodeList[idx].topDiffIs(diffh, diffs)
-	idx--
-
-	// ... following nodes also get diffs from next nodes, hence we add diffs to diffh
-	// we also propagate error along constant error carousel using diffs
-	for idx >= 0 {
-		loss += lossLayer(lw.NodeList[idx].State.H, yList[idx])
-		diffh = bottomDiffLayer(lw.NodeList[idx].State.H, yList[idx])
+	lw.Backward(dy)
+	return loss
+}
+
+// Forward propagates an input sequence from the current state, returning the output layer predictions.
+// The state is left at the end of the sequence.
+func (lw *LSTMnetwork) Forward(xList [][]float64) [][]float64 {
+	lw.XlistClear()
+	for _, x := range xList {
+		lw.xlistAdd(x, lw.s, lw.h)
+	}
+	if n := len(xList); n > 0 {
+		lw.s = append([]float64(nil), lw.NodeList[n-1].State.s...)
+		lw.h = append([]float64(nil), lw.NodeList[n-1].State.H...)
+	}
+	return lw.headForward()
+}
+
+// Backward back-propagates dy, the loss gradient w.r.t. the output layer predictions of the
+// current input sequence (nil: not in loss). Will *NOT* update parameters.
+func (lw *LSTMnetwork) Backward(dy [][]float64) {
+	if len(dy) != len(lw.xList) {
+		panic("lw.Backward ERROR 1")
+	}
+	for idx := len(lw.xList) - 1; idx >= 0; idx-- {
+		// output layer: gradient of the loss w.r.t. the hidden state
+		var diffh []float64
+		if dy[idx] != nil {
+			diffh = lw.head.grad(&lw.hc[idx], dy[idx])
+		} else {
+			diffh = zeros(1, lw.param.mem_cell_ct)[0]
+		}
+		if idx == len(lw.xList)-1 {
+			// last node only gets diffs from label; here s is not affecting loss due to h(t+1), hence we set equal to zero
+			lw.NodeList[idx].topDiffIs(diffh, lw.NodeList[idx].zero)
+			continue
+		}
+		// ... preceding nodes also get diffs from next nodes, hence we add diffs to diffh
+		// we also propagate error along constant error carousel using diffs
 		for i := 0; i < lw.param.mem_cell_ct; i++ {
 			diffh[i] += lw.NodeList[idx+1].State.bottomDiffh[i]
 		}
-		diffs = lw.NodeList[idx+1].State.bottomDiffs
-		lw.NodeList[idx].topDiffIs(diffh, diffs)
-		idx--
+		lw.NodeList[idx].topDiffIs(diffh, lw.NodeList[idx+1].State.bottomDiffs)
 	}
-	return loss
 }
 
 func (lw *LSTMnetwork) XlistClear() {
-	lw.xList = make([][]float64, lw.param.x_dim)
+	lw.xList = [][]float64{}
 }
 
 func (lw *LSTMnetwork) XlistAdd(x []float64) {
+	lw.xlistAdd(x, nil, nil)
+}
+
+// xlistAdd s0, h0: recurrent inputs of the first node (nil: at rest)
+func (lw *LSTMnetwork) xlistAdd(x, s0, h0 []float64) {
 	lw.xList = append(lw.xList, x)
 	if len(lw.xList) > len(lw.NodeList) {
 		// need to add new lstm node, create new state mem
-		ls := NewLTSMstate(lw.param.mem_cell_ct)
+		ls := NewLSTMstate(lw.param.mem_cell_ct)
 		lw.NodeList = append(lw.NodeList, NewLSTMnode(ls, lw.param))
 	}
 
@@ -294,7 +383,7 @@ func (lw *LSTMnetwork) XlistAdd(x []float64) {
 	idx := len(lw.xList) - 1
 	if idx == 0 {
 		// no recurrent inputs yet
-		lw.NodeList[idx].bottomDataIs(x, nil, nil)
+		lw.NodeList[idx].bottomDataIs(x, s0, h0)
 	} else {
 		sPrev := lw.NodeList[idx-1].State.s
 		hPrev := lw.NodeList[idx-1].State.H
@@ -3,102 +3,192 @@ package goann
 import "math"
 
 // LSTM follows the nomenclature of Kratzert et.al., 2018
-type LSTM struct{ Wf, Wg, Wi, Wo, Uf, Ug, Ui, Uo, Bf, Bg, Bi, Bo, h, c float64 }
+type LSTM struct {
+	Wf, Wg, Wi, Wo [][]float64 // input weights (nh x nx)
+	Uf, Ug, Ui, Uo [][]float64 // recurrent weights (nh x nh)
+	Bf, Bg, Bi, Bo []float64   // biases (nh)
+	h, c           []float64
+
+	// diffs (derivative of loss function w.r.t. all parameters)
+	dWf, dWg, dWi, dWo [][]float64
+	dUf, dUg, dUi, dUo [][]float64
+	dBf, dBg, dBi, dBo []float64
+	p, d               []float64 // flat buffers holding all of the above (parameters and diffs)
+	nx, nh             int
+}
+
+// lstmCache holds what a single cell needs, at a single timestep, to back-propagate
+type lstmCache struct{ x, g, i, f, o, c, c0, h0 []float64 }
 
 type LSTMlayers struct {
 	layer []LSTM
+	head  Dense         // output layer
+	cache [][]lstmCache // [layer][timestep]
+	hc    []denseCache  // [timestep]
 	eta   float64
 	nl    int
+	trunc int // BPTT truncation length (<=0: full sequence)
 }
 
-func (ls *LSTMlayers) reset() {
+// TruncateBPTT limits back-propagation through time to blocks of n timesteps (n<=0: no truncation)
+func (ls *LSTMlayers) TruncateBPTT(n int) { ls.trunc = n }
+
+// Reset zeroes the hidden and cell states of all layers
+func (ls *LSTMlayers) Reset() {
 	for i := 0; i < ls.nl; i++ {
-		// ls.layer[i].Wf = 0.
-		// ls.layer[i].Wg = 0.
-		// ls.layer[i].Wi = 0.
-		// ls.layer[i].Wo = 0.
-		// ls.layer[i].Uf = 0.
-		// ls.layer[i].Ug = 0.
-		// ls.layer[i].Ui = 0.
-		// ls.layer[i].Uo = 0.
-		// ls.layer[i].Bf = 0.
-		// ls.layer[i].Bg = 0.
-		// ls.layer[i].Bi = 0.
-		// ls.layer[i].Bo = 0.
-		ls.layer[i].h = 0.
-		ls.layer[i].c = 0.
+		for j := 0; j < ls.layer[i].nh; j++ {
+			ls.layer[i].h[j] = 0.
+			ls.layer[i].c[j] = 0.
+		}
 	}
 }
 
-func (l *LSTM) update(x float64) (g, i, f, o float64) {
-	g = l.Wg*x + l.Ug*l.h + l.Bg                   // candidate state
-	i = l.Wi*x + l.Ui*l.h + l.Bi                   // input gate
-	f = l.Wf*x + l.Uf*l.h + l.Bf                   // forget gate
-	o = l.Wo*x + l.Uo*l.h + l.Bo                   // output gate
-	l.c = sigmoid(f)*l.c + sigmoid(i)*math.Tanh(g) // update cell state
-	l.h = math.Tanh(l.c) * sigmoid(o)              // update hidden state
+// update advances the cell one timestep, returning the activated gates
+func (l *LSTM) update(x []float64) (g, i, f, o []float64) {
+	g, i, f, o = make([]float64, l.nh), make([]float64, l.nh), make([]float64, l.nh), make([]float64, l.nh)
+	for j := 0; j < l.nh; j++ {
+		g[j] = math.Tanh(dot(l.Wg[j], x) + dot(l.Ug[j], l.h) + l.Bg[j]) // candidate state
+		i[j] = sigmoid(dot(l.Wi[j], x) + dot(l.Ui[j], l.h) + l.Bi[j])   // input gate
+		f[j] = sigmoid(dot(l.Wf[j], x) + dot(l.Uf[j], l.h) + l.Bf[j])   // forget gate
+		o[j] = sigmoid(dot(l.Wo[j], x) + dot(l.Uo[j], l.h) + l.Bo[j])   // output gate
+	}
+	h := make([]float64, l.nh) // h(t-1) is needed until all gates are computed
+	for j := 0; j < l.nh; j++ {
+		l.c[j] = f[j]*l.c[j] + i[j]*g[j] // update cell state
+		h[j] = math.Tanh(l.c[j]) * o[j]  // update hidden state
+	}
+	copy(l.h, h)
 	return
 }
 
-func (l *LSTM) backpropagate(e, x, g, i, f, o, c0, h0 float64) {
-	// Gradient with respect to output gate weights:
-	do := e * math.Tanh(l.c) * sigmoidPrime(o)
-	l.Bo += do
-	l.Wo += do * x
-	l.Uo += do * h0
-
-	// Gradient with respect to forget gate weights:
-	dc := e * o * tanhPrime(l.c)
-	df := dc * c0 * sigmoidPrime(f)
-	l.Bf += df
-	l.Wf += df * x
-	l.Uf += df * h0
-
-	// Gradient with respect to input gate weights:
-	di := dc * g * sigmoidPrime(i)
-	l.Bi += di
-	l.Wi += di * x
-	l.Ui += di * h0
-	dg := dc * i * tanhPrime(g)
-	l.Bg += dg
-	l.Wg += dg * x
-	l.Ug += dg * h0
-}
-
-func (ls *LSTMlayers) Train(input, trainer []float64) {
-	// forward propagate
-	ls.reset()
+// backpropagate a single timestep. dh: loss gradient w.r.t. h(t) (from the layer above, the
+// prediction and from h(t+1)); dc: gradient carried along the cell state from c(t+1).
+// Parameter diffs are accumulated; returned are the gradients w.r.t. x(t), h(t-1) and c(t-1).
+func (l *LSTM) backpropagate(s *lstmCache, dh, dc []float64) (dx, dh0, dc0 []float64) {
+	dx, dh0, dc0 = make([]float64, l.nx), make([]float64, l.nh), make([]float64, l.nh)
+	for j := 0; j < l.nh; j++ {
+		tc := math.Tanh(s.c[j])
+		dcj := dc[j] + dh[j]*s.o[j]*(1.-tc*tc)
+		dc0[j] = dcj * s.f[j] // constant error carousel
 
-	// save predicted timeseries
-	ypred := make([]float64, len(trainer))
+		// diffs w.r.t. vector inside sigma / tanh function
+		do := dh[j] * tc * s.o[j] * (1. - s.o[j])
+		df := dcj * s.c0[j] * s.f[j] * (1. - s.f[j])
+		di := dcj * s.g[j] * s.i[j] * (1. - s.i[j])
+		dg := dcj * s.i[j] * (1. - s.g[j]*s.g[j])
 
-	// saving recursive states for back-propagation
-	g, i, f, o := make([][]float64, ls.nl), make([][]float64, ls.nl), make([][]float64, ls.nl), make([][]float64, ls.nl)
-	clast, hlast := make([][]float64, ls.nl), make([][]float64, ls.nl)
-	for k := 1; k < ls.nl; k++ { // initialize
-		g[k], i[k], f[k], o[k] = make([]float64, len(trainer)), make([]float64, len(trainer)), make([]float64, len(trainer)), make([]float64, len(trainer))
-		clast[k], hlast[k] = make([]float64, len(trainer)), make([]float64, len(trainer))
+		l.dBo[j] += do
+		l.dBf[j] += df
+		l.dBi[j] += di
+		l.dBg[j] += dg
+		for k := 0; k < l.nx; k++ {
+			l.dWo[j][k] += do * s.x[k]
+			l.dWf[j][k] += df * s.x[k]
+			l.dWi[j][k] += di * s.x[k]
+			l.dWg[j][k] += dg * s.x[k]
+			dx[k] += l.Wo[j][k]*do + l.Wf[j][k]*df + l.Wi[j][k]*di + l.Wg[j][k]*dg
+		}
+		for k := 0; k < l.nh; k++ {
+			l.dUo[j][k] += do * s.h0[k]
+			l.dUf[j][k] += df * s.h0[k]
+			l.dUi[j][k] += di * s.h0[k]
+			l.dUg[j][k] += dg * s.h0[k]
+			dh0[k] += l.Uo[j][k]*do + l.Uf[j][k]*df + l.Ui[j][k]*di + l.Ug[j][k]*dg
+		}
 	}
+	return
+}
 
+// Forward propagates an input sequence from the current state, saving recursive states for back-propagation
+func (ls *LSTMlayers) Forward(input [][]float64) [][]float64 {
+	ypred := make([][]float64, len(input))
+	for k := 0; k < ls.nl; k++ {
+		ls.cache[k] = make([]lstmCache, len(input))
+	}
+	ls.hc = make([]denseCache, len(input))
 	for j, v := range input {
-		clast[0][j], hlast[0][j] = ls.layer[0].c, ls.layer[0].h
-		g[0][j], i[0][j], f[0][j], o[0][j] = ls.layer[0].update(v)
-
-		// deep learning
-		for k := 1; k < ls.nl; k++ {
-			clast[k][j], hlast[k][j] = ls.layer[k].c, ls.layer[k].h
-			g[k][j], i[k][j], f[k][j], o[k][j] = ls.layer[k].update(ls.layer[k-1].h)
+		x := v
+		for k := 0; k < ls.nl; k++ { // deep learning
+			l := &ls.layer[k]
+			s := &ls.cache[k][j]
+			s.x = x
+			s.c0, s.h0 = append([]float64(nil), l.c...), append([]float64(nil), l.h...)
+			s.g, s.i, s.f, s.o = l.update(x)
+			s.c = append([]float64(nil), l.c...)
+			x = append([]float64(nil), l.h...)
 		}
 
 		// prediction
-		ypred[j] = ls.layer[ls.nl-1].h
+		ypred[j] = ls.head.train(&ls.hc[j], x)
 	}
+	return ypred
+}
 
-	// back propagate errors
-	for j, y := range trainer {
-		e := y - ypred[j] // mse
-		for k := 0; k < ls.nl; k++ {
-			ls.layer[k].backpropagate(ls.eta*e, input[j], g[k][j], i[k][j], f[k][j], o[k][j], clast[k][j], hlast[k][j])
+// Backward back-propagates through the output layer, through time and through the stacked layers.
+// dy: loss gradient w.r.t. each prediction made in the last call to Forward (nil: not in loss).
+func (ls *LSTMlayers) Backward(dy [][]float64) {
+	nt := len(dy)
+	dhnext, dcnext := make([][]float64, ls.nl), make([][]float64, ls.nl)
+	for j := nt - 1; j >= 0; j-- {
+		if j == nt-1 || (ls.trunc > 0 && (nt-1-j)%ls.trunc == 0) {
+			// here h(t+1) and c(t+1) are not affecting loss (end of sequence, or truncated)
+			for k := 0; k < ls.nl; k++ {
+				dhnext[k], dcnext[k] = make([]float64, ls.layer[k].nh), make([]float64, ls.layer[k].nh)
+			}
 		}
+		var dx []float64
+		if dy[j] != nil {
+			dx = ls.head.grad(&ls.hc[j], dy[j])
+		}
+		for k := ls.nl - 1; k >= 0; k-- {
+			dh := dhnext[k]
+			for i := range dx {
+				dh[i] += dx[i] // layer above (or prediction) plus h(t+1)
+			}
+			dx, dhnext[k], dcnext[k] = ls.layer[k].backpropagate(&ls.cache[k][j], dh, dcnext[k])
+		}
+	}
+}
+
+// Params returns the parameters of all layers, including the output layer
+func (ls *LSTMlayers) Params() []Param {
+	ps := make([]Param, 0, ls.nl+1)
+	for k := 0; k < ls.nl; k++ {
+		ps = append(ps, Param{W: ls.layer[k].p, D: ls.layer[k].d})
+	}
+	return append(ps, ls.head.params())
+}
+
+// Train input: sequence of forcing vectors (one per timestep); trainer: sequence of target vectors.
+// The recurrent layers and the output layer are trained jointly, back-propagating the error e = pred-label
+// (i.e., half the gradient of MSE). Returns the sum of squared errors.
+func (ls *LSTMlayers) Train(input, trainer [][]float64) float64 {
+	return TrainSequence(ls, input, trainer, halfMSE{}, ls.eta)
+}
+
+// TrainWeighted as Train, where the loss of every timestep is scaled by its weight (nil: 1). Returns an
+// error when the input, trainer and weight lengths differ.
+func (ls *LSTMlayers) TrainWeighted(input, trainer [][]float64, wts []float64) (float64, error) {
+	return TrainSequenceWeighted(ls, input, trainer, wts, halfMSE{}, ls.eta)
+}
+
+// Step advances the (trained) network one timestep from its current state, returning the prediction.
+// Weights are left untouched.
+func (ls *LSTMlayers) Step(x []float64) []float64 {
+	for k := 0; k < ls.nl; k++ {
+		ls.layer[k].update(x)
+		x = ls.layer[k].h
+	}
+	return ls.head.predict(x)
+}
+
+// Predict runs the (trained) network over an input sequence, starting from rest. The state is
+// left at the end of the sequence, such that it can be snapshot (State) and advanced using Step.
+func (ls *LSTMlayers) Predict(input [][]float64) [][]float64 {
+	ls.Reset()
+	o := make([][]float64, len(input))
+	for j, x := range input {
+		o[j] = ls.Step(x)
 	}
+	return o
 }
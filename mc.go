@@ -0,0 +1,132 @@
+package goann
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MCPrediction summarizes the samples of a Monte Carlo dropout prediction (Gal and Ghahramani, 2016)
+type MCPrediction struct {
+	Mean, Var [][]float64   // sample mean and variance [timestep][output]
+	P         []float64     // requested probabilities (e.g., .05, .5, .95)
+	Q         [][][]float64 // sample quantiles at P [probability][timestep][output]
+}
+
+// mcSummary reduces samples [sample][timestep][output] to their moments and quantiles at ps.
+// Without samples, the prediction is empty.
+func mcSummary(samples [][][]float64, ps []float64) MCPrediction {
+	if len(samples) == 0 {
+		return MCPrediction{P: ps, Q: make([][][]float64, len(ps))}
+	}
+	ns, nt := len(samples), len(samples[0])
+	o := MCPrediction{Mean: make([][]float64, nt), Var: make([][]float64, nt), P: ps, Q: make([][][]float64, len(ps))}
+	for i := range ps {
+		o.Q[i] = make([][]float64, nt)
+	}
+	v := make([]float64, ns)
+	for t := 0; t < nt; t++ {
+		ny := len(samples[0][t])
+		o.Mean[t], o.Var[t] = make([]float64, ny), make([]float64, ny)
+		for i := range ps {
+			o.Q[i][t] = make([]float64, ny)
+		}
+		for k := 0; k < ny; k++ {
+			for s := range samples {
+				v[s] = samples[s][t][k]
+				o.Mean[t][k] += v[s]
+			}
+			o.Mean[t][k] /= float64(ns)
+			for _, x := range v {
+				o.Var[t][k] += (x - o.Mean[t][k]) * (x - o.Mean[t][k])
+			}
+			if ns > 1 {
+				o.Var[t][k] /= float64(ns - 1)
+			}
+			sort.Float64s(v)
+			for i, p := range ps {
+				o.Q[i][t][k] = empiricalQuantile(v, p)
+			}
+		}
+	}
+	return o
+}
+
+// empiricalQuantile linearly interpolates the sorted sample v at probability p
+func empiricalQuantile(v []float64, p float64) float64 {
+	h := p * float64(len(v)-1)
+	i := int(math.Floor(h))
+	switch {
+	case i < 0:
+		return v[0]
+	case i >= len(v)-1:
+		return v[len(v)-1]
+	}
+	return v[i] + (h-float64(i))*(v[i+1]-v[i])
+}
+
+// mcDropout repeats a prediction nSamples times with the output layer's dropout left on
+func mcDropout(head *Dense, predict func() [][]float64, nSamples int, ps []float64) MCPrediction {
+	if nSamples < 1 {
+		return mcSummary(nil, ps)
+	}
+	head.mc = true
+	defer func() { head.mc = false }()
+	samples := make([][][]float64, nSamples)
+	for s := range samples {
+		samples[s] = predict()
+	}
+	return mcSummary(samples, ps)
+}
+
+// PredictMC feeds every input vector nSamples times with hidden node dropout left on (see SetDropout),
+// returning the sample mean, variance and quantiles at probabilities ps of each output (empty when nSamples < 1)
+func (nn *Network) PredictMC(input [][]float64, nSamples int, ps ...float64) MCPrediction {
+	if nSamples < 1 {
+		return mcSummary(nil, ps)
+	}
+	samples := make([][][]float64, nSamples)
+	for s := range samples {
+		samples[s] = make([][]float64, len(input))
+		for t, x := range input {
+			samples[s][t] = nn.forward(x, true)
+		}
+	}
+	return mcSummary(samples, ps)
+}
+
+// SetDropout sets the dropout rate applied to the last hidden layer, feeding the output layer, during training (default 0)
+func (ls *LSTMlayers) SetDropout(rate float64) { ls.head.rate = rate }
+
+// PredictMC runs Predict nSamples times with dropout left on, returning the sample mean, variance
+// and quantiles at probabilities ps of the predicted sequence
+func (ls *LSTMlayers) PredictMC(input [][]float64, nSamples int, ps ...float64) MCPrediction {
+	return mcDropout(&ls.head, func() [][]float64 { return ls.Predict(input) }, nSamples, ps)
+}
+
+// SetDropout sets the dropout rate applied to the last hidden layer, feeding the output layer, during training (default 0)
+func (gs *GRUlayers) SetDropout(rate float64) { gs.head.rate = rate }
+
+// PredictMC runs Predict nSamples times with dropout left on (see LSTMlayers.PredictMC)
+func (gs *GRUlayers) PredictMC(input [][]float64, nSamples int, ps ...float64) MCPrediction {
+	return mcDropout(&gs.head, func() [][]float64 { return gs.Predict(input) }, nSamples, ps)
+}
+
+// SetDropout sets the dropout rate applied to the hidden state, feeding the output layer, during training (default 0)
+func (ea *EALSTM) SetDropout(rate float64) { ea.head.rate = rate }
+
+// PredictMC runs Predict nSamples times over a basin with dropout left on (see LSTMlayers.PredictMC)
+func (ea *EALSTM) PredictMC(b Basin, nSamples int, ps ...float64) (MCPrediction, error) {
+	if err := ea.SetStatic(b.Static); err != nil {
+		return MCPrediction{}, fmt.Errorf("PredictMC: basin %s: %v", b.ID, err)
+	}
+	return mcDropout(&ea.head, func() [][]float64 { return ea.predict(b.Input) }, nSamples, ps), nil
+}
+
+// SetDropout sets the dropout rate applied to the hidden state, feeding the output layer, during training (default 0)
+func (lw *LSTMnetwork) SetDropout(rate float64) { lw.head.rate = rate }
+
+// PredictMC runs Predict nSamples times with dropout left on (see LSTMlayers.PredictMC)
+func (lw *LSTMnetwork) PredictMC(xList [][]float64, nSamples int, ps ...float64) MCPrediction {
+	return mcDropout(&lw.head, func() [][]float64 { return lw.Predict(xList) }, nSamples, ps)
+}
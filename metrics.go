@@ -0,0 +1,47 @@
+package goann
+
+import "math"
+
+// nse Nash-Sutcliffe efficiency factor, where every timestep is weighted by w (nil: 1).
+// Missing observations are skipped.
+func nse(obs, sim, w []float64) float64 {
+	ob, c := 0., 0.
+	for i, v := range obs {
+		if !math.IsNaN(v) {
+			ob += weightAt(w, i) * v
+			c += weightAt(w, i)
+		}
+	}
+	ob /= c
+	n, d := 0., 0.
+	for i, o := range obs {
+		if math.IsNaN(o) {
+			continue
+		}
+		n += weightAt(w, i) * (sim[i] - o) * (sim[i] - o)
+		d += weightAt(w, i) * (o - ob) * (o - ob)
+	}
+	return 1. - n/d
+}
+
+// rmse root-mean-squared error, where every timestep is weighted by w (nil: 1).
+// Missing observations are skipped.
+func rmse(obs, sim, w []float64) float64 {
+	s, c := 0., 0.
+	for i, o := range obs {
+		if math.IsNaN(o) {
+			continue
+		}
+		s += weightAt(w, i) * (sim[i] - o) * (sim[i] - o)
+		c += weightAt(w, i)
+	}
+	return math.Sqrt(s / c)
+}
+
+// weightAt returns the i-th weight (nil: 1)
+func weightAt(w []float64, i int) float64 {
+	if w == nil {
+		return 1.
+	}
+	return w[i]
+}
@@ -0,0 +1,81 @@
+package goann
+
+import (
+	"fmt"
+	"math"
+)
+
+// NaNStrategy sets how missing (NaN) inputs are handled. (Missing targets need no handling:
+// every trainer excludes NaN targets from the loss and its gradients.)
+type NaNStrategy int
+
+const (
+	SkipNaN   NaNStrategy = iota // samples having any missing input are excluded from the loss (their inputs are imputed, such that recurrent states carry through)
+	ImputeNaN                    // missing inputs are replaced by the mean of their (observed) column
+	MaskNaN                      // as ImputeNaN, with a 0/1 missing-indicator appended to the input vector for every input column
+)
+
+// FillNaN returns copies of the input (sequence of input vectors) and trainer (sequence of target
+// vectors) where missing inputs are handled according to strategy s. The trainer may be nil (e.g.,
+// when predicting), in which case so is the returned copy. Returns an error when the input vectors
+// differ in length, or the trainer and input do.
+func FillNaN(input, trainer [][]float64, s NaNStrategy) ([][]float64, [][]float64, error) {
+	if trainer != nil && len(trainer) != len(input) {
+		return nil, nil, fmt.Errorf("FillNaN: input (%d) and trainer (%d) lengths differ", len(input), len(trainer))
+	}
+	nx := 0
+	if len(input) > 0 {
+		nx = len(input[0])
+	}
+	for t, x := range input {
+		if len(x) != nx {
+			return nil, nil, fmt.Errorf("FillNaN: input %d has %d values, expecting %d", t, len(x), nx)
+		}
+	}
+	mean, cnt := make([]float64, nx), make([]float64, nx)
+	for _, x := range input {
+		for i, v := range x {
+			if !math.IsNaN(v) {
+				mean[i] += v
+				cnt[i]++
+			}
+		}
+	}
+	for i := range mean {
+		if cnt[i] > 0. {
+			mean[i] /= cnt[i]
+		}
+	}
+
+	xs, ys := make([][]float64, len(input)), [][]float64(nil)
+	if trainer != nil {
+		ys = make([][]float64, len(trainer))
+	}
+	for t, x := range input {
+		w := nx
+		if s == MaskNaN {
+			w *= 2
+		}
+		xs[t] = make([]float64, w)
+		miss := false
+		for i, v := range x {
+			if math.IsNaN(v) {
+				v, miss = mean[i], true
+				if s == MaskNaN {
+					xs[t][nx+i] = 1.
+				}
+			}
+			xs[t][i] = v
+		}
+		if trainer == nil {
+			continue
+		}
+		ys[t] = append([]float64(nil), trainer[t]...)
+		if miss && s == SkipNaN {
+			for k := range ys[t] {
+				ys[t][k] = math.NaN()
+			}
+		}
+	}
+	return xs, ys, nil
+}
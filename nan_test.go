@@ -0,0 +1,39 @@
+package goann
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFillNaN(t *testing.T) {
+	nan := math.NaN()
+	xs := [][]float64{{1., nan}, {2., 3.}, {3., 5.}}
+	ys := [][]float64{{1.}, {2.}, {3.}}
+
+	x, y, err := FillNaN(xs, ys, SkipNaN)
+	if err != nil || x[0][1] != 4. || !math.IsNaN(y[0][0]) || y[1][0] != 2. {
+		t.Errorf("SkipNaN: %v %v %v", x, y, err)
+	}
+	x, y, err = FillNaN(xs, ys, ImputeNaN)
+	if err != nil || x[0][1] != 4. || y[0][0] != 1. {
+		t.Errorf("ImputeNaN: %v %v %v", x, y, err)
+	}
+	x, _, err = FillNaN(xs, ys, MaskNaN)
+	if err != nil || !equalFloats(x[0], []float64{1., 4., 0., 1.}) || !equalFloats(x[1], []float64{2., 3., 0., 0.}) {
+		t.Errorf("MaskNaN: %v %v", x, err)
+	}
+	if !math.IsNaN(xs[0][1]) || ys[0][0] != 1. {
+		t.Error("inputs modified")
+	}
+
+	x, y, err = FillNaN(xs, nil, ImputeNaN) // inference: no targets
+	if err != nil || y != nil || len(x) != 3 || x[0][1] != 4. {
+		t.Errorf("nil trainer: %v %v %v", x, y, err)
+	}
+	if _, _, err := FillNaN(xs, ys[:2], ImputeNaN); err == nil {
+		t.Error("short trainer accepted")
+	}
+	if _, _, err := FillNaN([][]float64{{1.}, {2., 3.}}, nil, ImputeNaN); err == nil {
+		t.Error("ragged inputs accepted")
+	}
+}
@@ -0,0 +1,192 @@
+package goann
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+// NCSeries is a variable of multi-basin output, e.g., the predictions of every basin
+type NCSeries struct {
+	Name, Units string
+	Data        [][]float64 // [basin][timestep]; NaN: missing
+}
+
+// WriteNetCDF writes multi-basin series (e.g., the simulated flows, or PredictMC means and quantiles,
+// of every basin) to a NetCDF (64-bit offset) file following the CF conventions for time series:
+// dimensions (time, basin), a "time" variable (days since 1970-01-01), a "basin_id" variable holding
+// the station IDs and one (time, basin) double variable per series, where NaN is written as the
+// _FillValue -9999.
+func WriteNetCDF(fp string, ts []time.Time, ids []string, series ...NCSeries) error {
+	nt, nb, nid := len(ts), len(ids), 1
+	for _, id := range ids {
+		if len(id) > nid {
+			nid = len(id)
+		}
+	}
+
+	tv := make([]float64, nt)
+	for i, t := range ts {
+		tv[i] = float64(t.Unix())/86400. + float64(t.Nanosecond())/86400e9
+	}
+	idb := make([]byte, nb*nid)
+	for j, id := range ids {
+		copy(idb[j*nid:], id)
+	}
+	vars := []ncOut{
+		{name: "time", dims: []int{0}, typ: ncDouble, data: ncEncode(tv),
+			attrs: []ncAttr{{"standard_name", "time"}, {"units", "days since 1970-01-01 00:00:00"}, {"calendar", "standard"}}},
+		{name: "basin_id", dims: []int{1, 2}, typ: ncChar, data: idb,
+			attrs: []ncAttr{{"cf_role", "timeseries_id"}}},
+	}
+	for _, s := range series {
+		if len(s.Data) != nb {
+			return fmt.Errorf("WriteNetCDF: series %s has %d basins, expecting %d", s.Name, len(s.Data), nb)
+		}
+		x := make([]float64, nt*nb)
+		for j, b := range s.Data {
+			if len(b) != nt {
+				return fmt.Errorf("WriteNetCDF: series %s basin %s has %d timesteps, expecting %d", s.Name, ids[j], len(b), nt)
+			}
+			for i, v := range b {
+				if math.IsNaN(v) {
+					v = -9999.
+				}
+				x[i*nb+j] = v
+			}
+		}
+		a := []ncAttr{{"_FillValue", []float64{-9999.}}}
+		if s.Units != "" {
+			a = append(a, ncAttr{"units", s.Units})
+		}
+		vars = append(vars, ncOut{name: s.Name, dims: []int{0, 1}, typ: ncDouble, data: ncEncode(x), attrs: a})
+	}
+	dims := []NCDim{{Name: "time", Len: nt}, {Name: "basin", Len: nb}, {Name: "id_len", Len: nid}}
+	gatts := []ncAttr{{"Conventions", "CF-1.6"}, {"featureType", "timeSeries"}}
+
+	f, err := os.Create(fp)
+	if err != nil {
+		return fmt.Errorf("WriteNetCDF: %v", err)
+	}
+	w := bufio.NewWriter(f)
+	if err := ncWrite(w, dims, gatts, vars); err != nil {
+		f.Close()
+		return fmt.Errorf("WriteNetCDF %s: %v", fp, err)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("WriteNetCDF %s: %v", fp, err)
+	}
+	return f.Close()
+}
+
+// ncAttr is an attribute to be written, its value either a string or []float64 (written as doubles)
+type ncAttr struct {
+	name string
+	val  interface{}
+}
+
+// ncOut is a (non-record) variable to be written, its data encoded
+type ncOut struct {
+	name  string
+	dims  []int
+	attrs []ncAttr
+	typ   int
+	data  []byte
+}
+
+// ncWrite writes a 64-bit offset file of fixed-size (non-record) dimensions and variables
+func ncWrite(w *bufio.Writer, dims []NCDim, gatts []ncAttr, vars []ncOut) error {
+	begin := make([]int64, len(vars))
+	off := int64(len(ncEncodeHeader(dims, gatts, vars, begin)))
+	for k, v := range vars {
+		begin[k] = off
+		off += pad4(int64(len(v.data)))
+	}
+	if _, err := w.Write(ncEncodeHeader(dims, gatts, vars, begin)); err != nil {
+		return err
+	}
+	for _, v := range vars {
+		if _, err := w.Write(v.data); err != nil {
+			return err
+		}
+		if _, err := w.Write(make([]byte, pad4(int64(len(v.data)))-int64(len(v.data)))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ncEncodeHeader(dims []NCDim, gatts []ncAttr, vars []ncOut, begin []int64) []byte {
+	var b bytes.Buffer
+	i32 := func(n int) { binary.Write(&b, binary.BigEndian, int32(n)) }
+	pad := func(n int) { b.Write(make([]byte, pad4(int64(n))-int64(n))) }
+	name := func(s string) {
+		i32(len(s))
+		b.WriteString(s)
+		pad(len(s))
+	}
+	list := func(tag, n int) {
+		if n == 0 {
+			tag = 0
+		}
+		i32(tag)
+		i32(n)
+	}
+	attrs := func(as []ncAttr) {
+		list(ncAttribute, len(as))
+		for _, a := range as {
+			name(a.name)
+			switch v := a.val.(type) {
+			case string:
+				i32(ncChar)
+				i32(len(v))
+				b.WriteString(v)
+				pad(len(v))
+			case []float64:
+				i32(ncDouble)
+				i32(len(v))
+				b.Write(ncEncode(v))
+			}
+		}
+	}
+
+	b.WriteString("CDF\x02")
+	i32(0) // numrecs
+	list(ncDimension, len(dims))
+	for _, d := range dims {
+		name(d.Name)
+		i32(d.Len)
+	}
+	attrs(gatts)
+	list(ncVariable, len(vars))
+	for k, v := range vars {
+		name(v.name)
+		i32(len(v.dims))
+		for _, d := range v.dims {
+			i32(d)
+		}
+		attrs(v.attrs)
+		i32(v.typ)
+		vsize := pad4(int64(len(v.data)))
+		if vsize > math.MaxUint32 {
+			vsize = math.MaxUint32
+		}
+		binary.Write(&b, binary.BigEndian, uint32(vsize))
+		binary.Write(&b, binary.BigEndian, begin[k])
+	}
+	return b.Bytes()
+}
+
+// ncEncode converts values to big-endian doubles
+func ncEncode(x []float64) []byte {
+	b := make([]byte, 8*len(x))
+	for i, v := range x {
+		binary.BigEndian.PutUint64(b[8*i:], math.Float64bits(v))
+	}
+	return b
+}
@@ -0,0 +1,791 @@
+package goann
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NetCDF classic format header tags and external types
+const (
+	ncDimension = 0x0A
+	ncVariable  = 0x0B
+	ncAttribute = 0x0C
+
+	ncByte   = 1
+	ncChar   = 2
+	ncShort  = 3
+	ncInt    = 4
+	ncFloat  = 5
+	ncDouble = 6
+)
+
+// ncFill are the default fill values of the numeric types (bytes have none)
+var ncFill = map[int]float64{
+	ncShort:  -32767,
+	ncInt:    -2147483647,
+	ncFloat:  float64(float32(9.9692099683868690e+36)),
+	ncDouble: 9.9692099683868690e+36,
+}
+
+// NCDim is a NetCDF dimension
+type NCDim struct {
+	Name   string
+	Len    int
+	Record bool // the unlimited dimension, its length being the number of records
+}
+
+// NCVar is a NetCDF variable
+type NCVar struct {
+	Name  string
+	Dims  []int                  // indices into NetCDF.Dims, slowest varying first
+	Attrs map[string]interface{} // values are string (text) or []float64 (numeric)
+	typ   int
+	begin int64
+}
+
+// NetCDF is an open NetCDF file, of the classic or 64-bit offset format (NetCDF-4/HDF5 files are
+// not supported). Variable values are read on demand, unpacked following CF conventions:
+// fill and missing values are NaN, packed values are scaled by scale_factor and add_offset.
+type NetCDF struct {
+	Dims    []NCDim
+	Attrs   map[string]interface{} // global attributes
+	Vars    []*NCVar
+	NumRecs int
+
+	r       io.ReaderAt
+	c       io.Closer
+	recsize int64 // bytes per record, over all record variables
+}
+
+// OpenNetCDF opens a NetCDF file and reads its header
+func OpenNetCDF(fp string) (*NetCDF, error) {
+	f, err := os.Open(fp)
+	if err != nil {
+		return nil, fmt.Errorf("OpenNetCDF: %v", err)
+	}
+	nc, err := ParseNetCDF(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("OpenNetCDF %s: %v", fp, err)
+	}
+	nc.c = f
+	return nc, nil
+}
+
+// Close closes the file opened by OpenNetCDF
+func (nc *NetCDF) Close() error {
+	if nc.c == nil {
+		return nil
+	}
+	return nc.c.Close()
+}
+
+// ParseNetCDF reads the header of a NetCDF classic or 64-bit offset file
+func ParseNetCDF(r io.ReaderAt) (*NetCDF, error) {
+	h := &ncHeader{r: bufio.NewReader(io.NewSectionReader(r, 0, math.MaxInt64)), left: ncReaderSize(r)}
+	magic := h.bytes(4)
+	if h.err != nil {
+		return nil, fmt.Errorf("header read fail: %v", h.err)
+	}
+	switch {
+	case string(magic) == "CDF\x01":
+	case string(magic) == "CDF\x02":
+		h.v2 = true
+	case string(magic[:3]) == "CDF":
+		return nil, fmt.Errorf("unsupported NetCDF format version %d", magic[3])
+	case string(magic[1:]) == "HDF":
+		return nil, fmt.Errorf("NetCDF-4 (HDF5) files are not supported")
+	default:
+		return nil, fmt.Errorf("not a NetCDF file")
+	}
+
+	nc := &NetCDF{r: r, NumRecs: h.int()}
+	if nc.NumRecs < 0 {
+		return nil, fmt.Errorf("indeterminate number of records (file being streamed)")
+	}
+	nd := h.list(ncDimension, 8) // name length and dimension length
+	for i := 0; i < nd && h.err == nil; i++ {
+		d := NCDim{Name: h.name(), Len: h.int()}
+		if d.Len == 0 {
+			d.Len, d.Record = nc.NumRecs, true
+		}
+		nc.Dims = append(nc.Dims, d)
+	}
+	nc.Attrs = h.attrs()
+	nv := h.list(ncVariable, 28) // name length, dimension ids, attributes, type, vsize and begin
+	for i := 0; i < nv && h.err == nil; i++ {
+		v := &NCVar{Name: h.name()}
+		n := h.count(h.int(), 4)
+		for k := 0; k < n && h.err == nil; k++ { // grown as read, as the reader's size may be unknown
+			d := h.int()
+			if h.err == nil && (d < 0 || d >= len(nc.Dims)) {
+				h.err = fmt.Errorf("variable %s: invalid dimension id %d", v.Name, d)
+			}
+			v.Dims = append(v.Dims, d)
+		}
+		v.Attrs = h.attrs()
+		v.typ = h.int()
+		h.int() // vsize, recomputed below as it is clipped for large variables
+		v.begin = h.offset()
+		if h.err == nil && ncSize(v.typ) == 0 {
+			h.err = fmt.Errorf("variable %s: unknown type %d", v.Name, v.typ)
+		}
+		nc.Vars = append(nc.Vars, v)
+	}
+	if h.err != nil {
+		return nil, fmt.Errorf("header read fail: %v", h.err)
+	}
+
+	var rv []*NCVar
+	for _, v := range nc.Vars {
+		if nc.record(v) {
+			rv = append(rv, v)
+		}
+	}
+	for _, v := range rv {
+		nc.recsize += pad4(nc.slabBytes(v))
+	}
+	if len(rv) == 1 { // a lone record variable is not padded
+		nc.recsize = nc.slabBytes(rv[0])
+	}
+	return nc, nil
+}
+
+// Var returns the named variable
+func (nc *NetCDF) Var(name string) (*NCVar, error) {
+	for _, v := range nc.Vars {
+		if v.Name == name {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("variable %q not found", name)
+}
+
+// Shape returns the dimension lengths of a variable
+func (nc *NetCDF) Shape(v *NCVar) []int {
+	o := make([]int, len(v.Dims))
+	for k, d := range v.Dims {
+		o[k] = nc.Dims[d].Len
+	}
+	return o
+}
+
+// Values returns every value of the named numeric variable, in row-major order (see Shape)
+func (nc *NetCDF) Values(name string) ([]float64, error) {
+	v, err := nc.Var(name)
+	if err != nil {
+		return nil, err
+	}
+	var o []float64
+	for rec := 0; rec < nc.slabs(v); rec++ {
+		x, err := nc.read(v, rec, 0, nc.slabLen(v))
+		if err != nil {
+			return nil, err
+		}
+		o = append(o, x...)
+	}
+	return o, nil
+}
+
+// Grid returns the values of a 2-dimensional numeric variable, e.g., a basin mask or cell areas, as [row][column]
+func (nc *NetCDF) Grid(name string) ([][]float64, error) {
+	v, err := nc.Var(name)
+	if err != nil {
+		return nil, err
+	}
+	sh := nc.Shape(v)
+	if len(sh) != 2 {
+		return nil, fmt.Errorf("variable %s: expecting 2 dimensions, found %d", name, len(sh))
+	}
+	x, err := nc.Values(name)
+	if err != nil {
+		return nil, err
+	}
+	o := make([][]float64, sh[0])
+	for i := range o {
+		o[i] = x[i*sh[1] : (i+1)*sh[1]]
+	}
+	return o, nil
+}
+
+// Strings returns the text of a character variable, one string per row of its last dimension
+// (e.g., station IDs stored as [station][strlen])
+func (nc *NetCDF) Strings(name string) ([]string, error) {
+	v, err := nc.Var(name)
+	if err != nil {
+		return nil, err
+	}
+	if v.typ != ncChar {
+		return nil, fmt.Errorf("variable %s is not of type char", name)
+	}
+	sh := nc.Shape(v)
+	n := 1
+	if len(sh) > 0 {
+		n = sh[len(sh)-1]
+	}
+	var b []byte
+	for rec := 0; rec < nc.slabs(v); rec++ {
+		x, err := nc.readRaw(v, rec, 0, nc.slabLen(v))
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, x...)
+	}
+	var o []string
+	for i := 0; i+n <= len(b) && n > 0; i += n {
+		o = append(o, strings.TrimRight(string(b[i:i+n]), "\x00 "))
+	}
+	return o, nil
+}
+
+// Times decodes a CF time variable (units "<days|hours|minutes|seconds> since <date>") of the
+// standard (Gregorian) calendar
+func (nc *NetCDF) Times(name string) ([]time.Time, error) {
+	v, err := nc.Var(name)
+	if err != nil {
+		return nil, err
+	}
+	units, _ := v.Attrs["units"].(string)
+	cal, _ := v.Attrs["calendar"].(string)
+	x, err := nc.Values(name)
+	if err != nil {
+		return nil, err
+	}
+	o, err := ncTimes(x, units, cal)
+	if err != nil {
+		return nil, fmt.Errorf("variable %s: %v", name, err)
+	}
+	return o, nil
+}
+
+// Point returns the series of a gridded (time, y, x) variable at the grid cell nearest to lat, lon.
+// The cell is located using the 2-dimensional latitude and longitude variables listed in the
+// variable's "coordinates" attribute when given, otherwise its 1-dimensional coordinate variables
+// (lat and lon then being y and x of a projected grid, in its units).
+func (nc *NetCDF) Point(name string, lat, lon float64) ([]float64, error) {
+	v, err := nc.gridVar(name)
+	if err != nil {
+		return nil, err
+	}
+	iy, ix, err := nc.cell(v, lat, lon)
+	if err != nil {
+		return nil, err
+	}
+	nx := nc.Dims[v.Dims[2]].Len
+	o := make([]float64, nc.slabs(v))
+	for t := range o {
+		x, err := nc.read(v, t, iy*nx+ix, 1)
+		if err != nil {
+			return nil, err
+		}
+		o[t] = x[0]
+	}
+	return o, nil
+}
+
+// BasinMean returns the series of a gridded (time, y, x) variable averaged over a basin, given a
+// mask of cell weights [y][x] (e.g., 1 inside the basin, 0 outside, or the fraction of every cell
+// covered by the basin). Missing cell values are excluded from the weighted mean.
+func (nc *NetCDF) BasinMean(name string, mask [][]float64) ([]float64, error) {
+	v, err := nc.gridVar(name)
+	if err != nil {
+		return nil, err
+	}
+	ny, nx := nc.Dims[v.Dims[1]].Len, nc.Dims[v.Dims[2]].Len
+	if len(mask) != ny {
+		return nil, fmt.Errorf("BasinMean: mask has %d rows, variable %s has %d", len(mask), name, ny)
+	}
+	r0, r1 := ny, -1 // rows spanned by the basin
+	for i, r := range mask {
+		if len(r) != nx {
+			return nil, fmt.Errorf("BasinMean: mask has %d columns, variable %s has %d", len(r), name, nx)
+		}
+		for _, w := range r {
+			if w > 0. {
+				if i < r0 {
+					r0 = i
+				}
+				r1 = i
+			}
+		}
+	}
+	if r1 < 0 {
+		return nil, fmt.Errorf("BasinMean: empty mask")
+	}
+
+	o := make([]float64, nc.slabs(v))
+	for t := range o {
+		x, err := nc.read(v, t, r0*nx, (r1-r0+1)*nx)
+		if err != nil {
+			return nil, err
+		}
+		s, sw := 0., 0.
+		for i := r0; i <= r1; i++ {
+			for j, w := range mask[i] {
+				if c := x[(i-r0)*nx+j]; w > 0. && !math.IsNaN(c) {
+					s += w * c
+					sw += w
+				}
+			}
+		}
+		if sw > 0. {
+			o[t] = s / sw
+		} else {
+			o[t] = math.NaN()
+		}
+	}
+	return o, nil
+}
+
+// PointTable returns a daily Table of the named gridded variables (e.g., "prcp", "tmax", "tmin") at
+// a point (see Point), ready to be joined to a streamflow record (e.g., flows.Join(met)). Timestamps
+// are truncated to the day.
+func (nc *NetCDF) PointTable(lat, lon float64, names ...string) (*Table, error) {
+	return nc.table(names, func(n string) ([]float64, error) { return nc.Point(n, lat, lon) })
+}
+
+// BasinTable returns a daily Table of the basin-averaged named gridded variables (see BasinMean and PointTable)
+func (nc *NetCDF) BasinTable(mask [][]float64, names ...string) (*Table, error) {
+	return nc.table(names, func(n string) ([]float64, error) { return nc.BasinMean(n, mask) })
+}
+
+func (nc *NetCDF) table(names []string, series func(string) ([]float64, error)) (*Table, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no variables named")
+	}
+	t := &Table{Columns: names}
+	for k, n := range names {
+		v, err := nc.gridVar(n)
+		if err != nil {
+			return nil, err
+		}
+		if k == 0 {
+			ts, err := nc.Times(nc.Dims[v.Dims[0]].Name)
+			if err != nil {
+				return nil, err
+			}
+			t.Time, t.Flag = make([]time.Time, len(ts)), make([]Flag, len(ts))
+			for i, d := range ts {
+				d = d.UTC()
+				t.Time[i] = time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+			}
+		}
+		x, err := series(n)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != t.Len() {
+			return nil, fmt.Errorf("variable %s: %d timesteps, expecting %d", n, len(x), t.Len())
+		}
+		t.Data = append(t.Data, x)
+	}
+	return t, nil
+}
+
+// gridVar returns the named variable, checking it is numeric with (time, y, x) dimensions
+func (nc *NetCDF) gridVar(name string) (*NCVar, error) {
+	v, err := nc.Var(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(v.Dims) != 3 {
+		return nil, fmt.Errorf("variable %s: expecting (time, y, x) dimensions, found %d dimensions", name, len(v.Dims))
+	}
+	if v.typ == ncChar {
+		return nil, fmt.Errorf("variable %s is not numeric", name)
+	}
+	return v, nil
+}
+
+// cell returns the grid cell (row, column) of a gridded variable nearest to lat, lon
+func (nc *NetCDF) cell(v *NCVar, lat, lon float64) (int, int, error) {
+	ny, nx := nc.Dims[v.Dims[1]].Len, nc.Dims[v.Dims[2]].Len
+	if cs, ok := v.Attrs["coordinates"].(string); ok {
+		var la, lo []float64
+		for _, c := range strings.Fields(cs) {
+			cv, err := nc.Var(c)
+			if err != nil || len(cv.Dims) != 2 || cv.Dims[0] != v.Dims[1] || cv.Dims[1] != v.Dims[2] {
+				continue
+			}
+			switch {
+			case ncIsAxis(cv, "latitude", "north", "N"):
+				la, _ = nc.Values(c)
+			case ncIsAxis(cv, "longitude", "east", "E"):
+				lo, _ = nc.Values(c)
+			}
+		}
+		if la != nil && lo != nil {
+			cos := math.Cos(lat * math.Pi / 180.)
+			k, dmin := 0, math.Inf(1)
+			for i := range la {
+				dy, dx := la[i]-lat, ncLonDiff(lo[i], lon)*cos
+				if d := dy*dy + dx*dx; d < dmin {
+					k, dmin = i, d
+				}
+			}
+			return k / nx, k % nx, nil
+		}
+	}
+
+	nearest := func(d int, x float64) (int, error) {
+		c, err := nc.Var(nc.Dims[d].Name)
+		if err != nil || len(c.Dims) != 1 {
+			return 0, fmt.Errorf("variable %s: no coordinate variable for dimension %s", v.Name, nc.Dims[d].Name)
+		}
+		cx, err := nc.Values(c.Name)
+		if err != nil {
+			return 0, err
+		}
+		lon := ncIsAxis(c, "longitude", "east", "E")
+		k, dmin := 0, math.Inf(1)
+		for i, ci := range cx {
+			dd := math.Abs(ci - x)
+			if lon {
+				dd = math.Abs(ncLonDiff(ci, x))
+			}
+			if dd < dmin {
+				k, dmin = i, dd
+			}
+		}
+		return k, nil
+	}
+	iy, err := nearest(v.Dims[1], lat)
+	if err != nil {
+		return 0, 0, err
+	}
+	ix, err := nearest(v.Dims[2], lon)
+	if err != nil {
+		return 0, 0, err
+	}
+	if iy >= ny || ix >= nx {
+		return 0, 0, fmt.Errorf("variable %s: coordinate variables do not match its dimensions", v.Name)
+	}
+	return iy, ix, nil
+}
+
+// record is true for variables varying along the record dimension
+func (nc *NetCDF) record(v *NCVar) bool { return len(v.Dims) > 0 && nc.Dims[v.Dims[0]].Record }
+
+// slabs returns the number of slabs of a variable, the length of its first dimension
+func (nc *NetCDF) slabs(v *NCVar) int {
+	if len(v.Dims) == 0 {
+		return 1
+	}
+	return nc.Dims[v.Dims[0]].Len
+}
+
+// slabLen returns the number of values of a slab, the product of all but the first dimension lengths
+func (nc *NetCDF) slabLen(v *NCVar) int {
+	n := 1
+	for k := 1; k < len(v.Dims); k++ {
+		n *= nc.Dims[v.Dims[k]].Len
+	}
+	return n
+}
+
+func (nc *NetCDF) slabBytes(v *NCVar) int64 { return int64(nc.slabLen(v) * ncSize(v.typ)) }
+
+// readRaw returns the bytes of n values of v, starting at value off of slab rec
+func (nc *NetCDF) readRaw(v *NCVar, rec, off, n int) ([]byte, error) {
+	sz := ncSize(v.typ)
+	o := v.begin + int64(off*sz)
+	if nc.record(v) {
+		o += int64(rec) * nc.recsize
+	} else {
+		o += int64(rec) * nc.slabBytes(v)
+	}
+	b := make([]byte, n*sz)
+	if m, err := nc.r.ReadAt(b, o); m < len(b) {
+		return nil, fmt.Errorf("variable %s: read fail: %v", v.Name, err)
+	}
+	return b, nil
+}
+
+// read returns n unpacked values of v, starting at value off of slab rec (the rec-th index of its first dimension)
+func (nc *NetCDF) read(v *NCVar, rec, off, n int) ([]float64, error) {
+	if v.typ == ncChar {
+		return nil, fmt.Errorf("variable %s is not numeric", v.Name)
+	}
+	b, err := nc.readRaw(v, rec, off, n)
+	if err != nil {
+		return nil, err
+	}
+	x := ncDecode(b, v.typ)
+	v.unpack(x)
+	return x, nil
+}
+
+// unpack sets fill and missing values to NaN and applies the scale_factor and add_offset of packed data
+func (v *NCVar) unpack(x []float64) {
+	attr := func(name string) (float64, bool) {
+		if a, ok := v.Attrs[name].([]float64); ok && len(a) > 0 {
+			return a[0], true
+		}
+		return 0., false
+	}
+	fill, hasFill := attr("_FillValue")
+	if !hasFill {
+		fill, hasFill = ncFill[v.typ]
+	}
+	miss, hasMiss := attr("missing_value")
+	scale, hasScale := attr("scale_factor")
+	offset, _ := attr("add_offset")
+	if !hasScale {
+		scale = 1.
+	}
+	for i, c := range x {
+		if (hasFill && c == fill) || (hasMiss && c == miss) {
+			x[i] = math.NaN()
+			continue
+		}
+		x[i] = c*scale + offset
+	}
+}
+
+// ncHeader reads a NetCDF header, holding the first error encountered
+type ncHeader struct {
+	r    *bufio.Reader
+	v2   bool  // 64-bit offsets
+	left int64 // bytes left to read, bounding the counts read from the header
+	err  error
+}
+
+// ncReaderSize returns the size of a file or in-memory reader (unbounded when unknown)
+func ncReaderSize(r io.ReaderAt) int64 {
+	switch s := r.(type) {
+	case interface{ Size() int64 }:
+		return s.Size()
+	case interface{ Stat() (os.FileInfo, error) }:
+		if fi, err := s.Stat(); err == nil {
+			return fi.Size()
+		}
+	}
+	return math.MaxInt64
+}
+
+func (h *ncHeader) int() int {
+	b := h.bytes(4)
+	if h.err != nil {
+		return 0
+	}
+	return int(int32(binary.BigEndian.Uint32(b)))
+}
+
+func (h *ncHeader) offset() int64 {
+	if !h.v2 {
+		return int64(uint32(h.int()))
+	}
+	b := h.bytes(8)
+	if h.err != nil {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+// bytes reads n bytes, and the padding to the following 4-byte boundary
+func (h *ncHeader) bytes(n int) []byte {
+	if h.err != nil {
+		return nil
+	}
+	if n < 0 || n > 1<<30 || pad4(int64(n)) > h.left {
+		h.err = fmt.Errorf("invalid header element size %d", n)
+		return nil
+	}
+	b := make([]byte, pad4(int64(n)))
+	if _, h.err = io.ReadFull(h.r, b); h.err != nil {
+		return nil
+	}
+	h.left -= int64(len(b))
+	return b[:n]
+}
+
+// count checks a number of header elements, each at least size bytes long, against the bytes left
+func (h *ncHeader) count(n, size int) int {
+	if h.err != nil {
+		return 0
+	}
+	if n < 0 || int64(n)*int64(size) > h.left {
+		h.err = fmt.Errorf("invalid header element count %d", n)
+		return 0
+	}
+	return n
+}
+
+func (h *ncHeader) name() string { return string(h.bytes(h.int())) }
+
+// list reads the tag and length of a list of elements at least size bytes long, returning 0 when absent
+func (h *ncHeader) list(tag, size int) int {
+	t, n := h.int(), h.int()
+	if h.err == nil && t != tag && (t != 0 || n != 0) {
+		h.err = fmt.Errorf("unexpected header tag %d", t)
+	}
+	return h.count(n, size)
+}
+
+func (h *ncHeader) attrs() map[string]interface{} {
+	n := h.list(ncAttribute, 12) // name length, type and number of values
+	a := map[string]interface{}{}
+	for i := 0; i < n && h.err == nil; i++ {
+		name := h.name()
+		typ, ne := h.int(), h.int()
+		sz := ncSize(typ)
+		if h.err == nil && sz == 0 {
+			h.err = fmt.Errorf("attribute %s: unknown type %d", name, typ)
+		}
+		b := h.bytes(ne * sz)
+		if h.err != nil {
+			break
+		}
+		if typ == ncChar {
+			a[name] = strings.TrimRight(string(b), "\x00")
+		} else {
+			a[name] = ncDecode(b, typ)
+		}
+	}
+	return a
+}
+
+// ncSize returns the size in bytes of a value of an external type
+func ncSize(typ int) int {
+	switch typ {
+	case ncByte, ncChar:
+		return 1
+	case ncShort:
+		return 2
+	case ncInt, ncFloat:
+		return 4
+	case ncDouble:
+		return 8
+	}
+	return 0
+}
+
+// ncDecode converts big-endian values of a numeric external type
+func ncDecode(b []byte, typ int) []float64 {
+	o := make([]float64, len(b)/ncSize(typ))
+	for i := range o {
+		switch typ {
+		case ncByte, ncChar:
+			o[i] = float64(int8(b[i]))
+		case ncShort:
+			o[i] = float64(int16(binary.BigEndian.Uint16(b[2*i:])))
+		case ncInt:
+			o[i] = float64(int32(binary.BigEndian.Uint32(b[4*i:])))
+		case ncFloat:
+			o[i] = float64(math.Float32frombits(binary.BigEndian.Uint32(b[4*i:])))
+		case ncDouble:
+			o[i] = math.Float64frombits(binary.BigEndian.Uint64(b[8*i:]))
+		}
+	}
+	return o
+}
+
+func pad4(n int64) int64 { return (n + 3) &^ 3 }
+
+// ncIsAxis checks the standard_name and units (e.g., "degrees_north") of a coordinate variable
+func ncIsAxis(v *NCVar, standardName, dir, d string) bool {
+	if s, _ := v.Attrs["standard_name"].(string); s == standardName {
+		return true
+	}
+	u, _ := v.Attrs["units"].(string)
+	return strings.HasPrefix(u, "degree") && (strings.HasSuffix(u, dir) || strings.HasSuffix(u, d))
+}
+
+// ncLonDiff returns the difference of two longitudes, within ±180°
+func ncLonDiff(a, b float64) float64 { return math.Mod(math.Mod(a-b+180., 360.)+360., 360.) - 180. }
+
+// ncTimes converts CF time coordinates to timestamps
+func ncTimes(x []float64, units, calendar string) ([]time.Time, error) {
+	switch strings.ToLower(calendar) {
+	case "", "standard", "gregorian", "proleptic_gregorian":
+	default:
+		return nil, fmt.Errorf("unsupported calendar %q", calendar)
+	}
+	sp := strings.SplitN(units, " since ", 2)
+	if len(sp) != 2 {
+		return nil, fmt.Errorf("invalid time units %q", units)
+	}
+	var dt float64 // seconds per unit
+	switch strings.ToLower(strings.TrimSpace(sp[0])) {
+	case "days", "day", "d":
+		dt = 86400.
+	case "hours", "hour", "hrs", "hr", "h":
+		dt = 3600.
+	case "minutes", "minute", "mins", "min":
+		dt = 60.
+	case "seconds", "second", "secs", "sec", "s":
+		dt = 1.
+	default:
+		return nil, fmt.Errorf("invalid time units %q", units)
+	}
+	t0, err := ncParseTime(sp[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid time units %q: %v", units, err)
+	}
+	o := make([]time.Time, len(x))
+	for i, v := range x {
+		if math.IsNaN(v) {
+			return nil, fmt.Errorf("missing time coordinate at index %d", i)
+		}
+		s := v * dt
+		d := math.Floor(s / 86400.)
+		o[i] = t0.AddDate(0, 0, int(d)).Add(time.Duration(math.Round((s - d*86400.) * 1e9)))
+	}
+	return o, nil
+}
+
+// ncParseTime parses the reference time of CF time units, e.g., "1980-1-1", "1980-01-01 00:00:00 UTC",
+// "1980-01-01T00:00:00Z" or "1980-01-01 00:00:00 -5:00"
+func ncParseTime(s string) (time.Time, error) {
+	f := strings.Fields(strings.Replace(strings.TrimSpace(s), "T", " ", 1))
+	if len(f) == 0 {
+		return time.Time{}, fmt.Errorf("no reference time")
+	}
+	ymd := strings.Split(f[0], "-")
+	if len(ymd) != 3 {
+		return time.Time{}, fmt.Errorf("invalid date %q", f[0])
+	}
+	var dmy [3]int
+	for k, c := range ymd {
+		n, err := strconv.Atoi(c)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q", f[0])
+		}
+		dmy[k] = n
+	}
+	var hms [3]float64
+	var tz float64 // hours
+	if len(f) > 1 {
+		for k, c := range strings.Split(strings.TrimSuffix(f[1], "Z"), ":") {
+			n, err := strconv.ParseFloat(c, 64)
+			if err != nil || k > 2 {
+				return time.Time{}, fmt.Errorf("invalid time %q", f[1])
+			}
+			hms[k] = n
+		}
+	}
+	if len(f) > 2 && (f[2][0] == '+' || f[2][0] == '-') {
+		hm := strings.Split(f[2], ":")
+		h, err := strconv.ParseFloat(hm[0], 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time zone %q", f[2])
+		}
+		tz = h
+		if len(hm) > 1 {
+			m, err := strconv.ParseFloat(hm[1], 64)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid time zone %q", f[2])
+			}
+			tz += math.Copysign(m/60., h)
+		}
+	}
+	t := time.Date(dmy[0], time.Month(dmy[1]), dmy[2], 0, 0, 0, 0, time.UTC)
+	return t.Add(time.Duration(math.Round(((hms[0]-tz)*3600. + hms[1]*60. + hms[2]) * 1e9))), nil
+}
@@ -0,0 +1,137 @@
+package goann
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNetCDFRoundTrip(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "q.nc")
+	ts := []time.Time{time.Date(2001, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2001, 3, 2, 0, 0, 0, 0, time.UTC)}
+	ids := []string{"02EC018", "02HB001"}
+	err := WriteNetCDF(fp, ts, ids,
+		NCSeries{Name: "Qsim", Units: "m3/s", Data: [][]float64{{1., 2.}, {3., math.NaN()}}},
+		NCSeries{Name: "Q90", Data: [][]float64{{5., 6.}, {7., 8.}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nc, err := OpenNetCDF(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+
+	s, err := nc.Strings("basin_id")
+	if err != nil || len(s) != 2 || s[0] != ids[0] || s[1] != ids[1] {
+		t.Errorf("basin_id %q %v", s, err)
+	}
+	tt, err := nc.Times("time")
+	if err != nil || len(tt) != 2 || !tt[0].Equal(ts[0]) || !tt[1].Equal(ts[1]) {
+		t.Errorf("time %v %v", tt, err)
+	}
+	q, err := nc.Values("Qsim") // (time, basin)
+	if err != nil || len(q) != 4 || q[0] != 1. || q[1] != 3. || q[2] != 2. || !math.IsNaN(q[3]) {
+		t.Errorf("Qsim %v %v", q, err)
+	}
+	v, _ := nc.Var("Qsim")
+	if u, _ := v.Attrs["units"].(string); u != "m3/s" {
+		t.Errorf("Qsim units %q", u)
+	}
+	if err := WriteNetCDF(fp, ts, ids, NCSeries{Name: "x", Data: [][]float64{{1., 2.}}}); err == nil {
+		t.Error("series missing a basin accepted")
+	}
+}
+
+// gridNetCDF encodes a (time, lat, lon) precipitation grid of 3 days, 2 rows and 3 columns, where
+// prcp = 10*day + 3*row + column, the last cell of the first day being missing
+func gridNetCDF(t *testing.T) []byte {
+	prcp := make([]float64, 18)
+	for i := range prcp {
+		prcp[i] = float64(10*(i/6) + i%6)
+	}
+	prcp[5] = -9999.
+	vars := []ncOut{
+		{name: "time", dims: []int{0}, typ: ncDouble, data: ncEncode([]float64{0., 1., 2.}),
+			attrs: []ncAttr{{"units", "days since 2000-01-01"}}},
+		{name: "lat", dims: []int{1}, typ: ncDouble, data: ncEncode([]float64{44., 45.}),
+			attrs: []ncAttr{{"standard_name", "latitude"}}},
+		{name: "lon", dims: []int{2}, typ: ncDouble, data: ncEncode([]float64{-80., -79., -78.}),
+			attrs: []ncAttr{{"standard_name", "longitude"}}},
+		{name: "prcp", dims: []int{0, 1, 2}, typ: ncDouble, data: ncEncode(prcp),
+			attrs: []ncAttr{{"_FillValue", []float64{-9999.}}}},
+	}
+	var b bytes.Buffer
+	w := bufio.NewWriter(&b)
+	if err := ncWrite(w, []NCDim{{Name: "time", Len: 3}, {Name: "lat", Len: 2}, {Name: "lon", Len: 3}}, nil, vars); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	return b.Bytes()
+}
+
+func TestNetCDFGrid(t *testing.T) {
+	nc, err := ParseNetCDF(bytes.NewReader(gridNetCDF(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := nc.Point("prcp", 44.9, 280.9) // nearest cell (45, -79), given a 0-360 longitude
+	if err != nil || len(p) != 3 || p[0] != 4. || p[2] != 24. {
+		t.Errorf("Point %v %v", p, err)
+	}
+	if p, _ := nc.Point("prcp", 45., -78.); !math.IsNaN(p[0]) || p[1] != 15. {
+		t.Errorf("Point at a missing cell %v", p)
+	}
+	mask := [][]float64{{0., 1., 1.}, {0., 0., 1.}}
+	m, err := nc.BasinMean("prcp", mask)
+	if err != nil || math.Abs(m[0]-1.5) > 1e-12 || math.Abs(m[1]-(11.+12.+15.)/3.) > 1e-12 {
+		t.Errorf("BasinMean %v %v", m, err)
+	}
+	tb, err := nc.BasinTable(mask, "prcp")
+	if err != nil || tb.Len() != 3 || !tb.Time[2].Equal(time.Date(2000, 1, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("BasinTable %v", err)
+	}
+	if _, err := nc.BasinMean("prcp", [][]float64{{1.}}); err == nil {
+		t.Error("mismatched mask accepted")
+	}
+}
+
+// TestNetCDFCorruptHeader changes every header byte of a valid file, expecting ParseNetCDF to
+// return (an error or not) rather than panic or allocate the counts read from the header
+func TestNetCDFCorruptHeader(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "q.nc")
+	ts := []time.Time{time.Unix(0, 0).UTC(), time.Unix(86400, 0).UTC()}
+	if err := WriteNetCDF(fp, ts, []string{"02HB001"}, NCSeries{Name: "q", Data: [][]float64{{1., 2.}}}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nc, err := ParseNetCDF(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nh := int(nc.Vars[0].begin) // header length
+	for i := 4; i < nh; i++ {
+		for _, v := range []byte{0x74, 0x80, 0xff} {
+			c := append([]byte(nil), b...)
+			c[i] = v
+			ParseNetCDF(bytes.NewReader(c))
+			ParseNetCDF(struct{ io.ReaderAt }{bytes.NewReader(c)}) // size unknown
+		}
+	}
+
+	c := append([]byte(nil), b...) // global attribute count of 0x74696d65
+	i := bytes.Index(c, []byte{0, 0, 0, ncAttribute, 0, 0, 0, 2})
+	binary.BigEndian.PutUint32(c[i+4:], 0x74696d65)
+	if _, err := ParseNetCDF(bytes.NewReader(c)); err == nil {
+		t.Error("attribute count 0x74696d65 accepted")
+	}
+}
@@ -0,0 +1,34 @@
+package goann
+
+import "math/rand"
+
+// NewEALSTM nx: number of dynamic inputs (forcings) per timestep; ns: number of static catchment attributes;
+// nh: number of hidden units; ny: number of outputs (targets) of the dense output layer having activation act; eta learning rate
+func NewEALSTM(nx, ns, nh, ny int, act Activation, eta float64) EALSTM {
+	nw := nh*ns + 3*nh*(nx+nh) // weights are randomized, biases start at zero
+	ea := EALSTM{
+		p:      make([]float64, nw+4*nh),
+		d:      make([]float64, nw+4*nh),
+		h:      zeros(1, nh)[0],
+		c:      zeros(1, nh)[0],
+		static: zeros(1, ns)[0],
+		head:   newDense(nh, ny, act),
+		eta:    eta,
+		nx:     nx,
+		ns:     ns,
+		nh:     nh,
+	}
+	for i := 0; i < nw; i++ {
+		ea.p[i] = rand.Float64()*0.2 - 0.1
+	}
+	p, d := ea.p, ea.d
+	ea.Wi = carve(&p, nh, ns)
+	ea.Wf, ea.Wg, ea.Wo = carve(&p, nh, nx), carve(&p, nh, nx), carve(&p, nh, nx)
+	ea.Uf, ea.Ug, ea.Uo = carve(&p, nh, nh), carve(&p, nh, nh), carve(&p, nh, nh)
+	ea.Bi, ea.Bf, ea.Bg, ea.Bo = carveVec(&p, nh), carveVec(&p, nh), carveVec(&p, nh), carveVec(&p, nh)
+	ea.dWi = carve(&d, nh, ns)
+	ea.dWf, ea.dWg, ea.dWo = carve(&d, nh, nx), carve(&d, nh, nx), carve(&d, nh, nx)
+	ea.dUf, ea.dUg, ea.dUo = carve(&d, nh, nh), carve(&d, nh, nh), carve(&d, nh, nh)
+	ea.dBi, ea.dBf, ea.dBg, ea.dBo = carveVec(&d, nh), carveVec(&d, nh), carveVec(&d, nh), carveVec(&d, nh)
+	return ea
+}
@@ -0,0 +1,46 @@
+package goann
+
+import "math/rand"
+
+// NewGRU nx: number of inputs (forcings) per timestep; nh: number of hidden units in each recurrent layer;
+// ny: number of outputs (targets) of the dense output layer having activation act; eta learning rate
+func NewGRU(nx int, nh []int, ny int, act Activation, eta float64) GRUlayers {
+	nl := len(nh)
+	layer := make([]GRU, nl)
+	for k := 0; k < nl; k++ {
+		m := nx
+		if k > 0 {
+			m = nh[k-1] // deep layers are fed by the hidden state of the layer below
+		}
+		layer[k] = newGRUcell(m, nh[k])
+	}
+	return GRUlayers{
+		layer: layer,
+		head:  newDense(nh[nl-1], ny, act),
+		cache: make([][]gruCache, nl),
+		eta:   eta,
+		nl:    nl,
+	}
+}
+
+func newGRUcell(nx, nh int) GRU {
+	nw := 3 * nh * (nx + nh) // weights are randomized, biases start at zero
+	l := GRU{
+		p:  make([]float64, nw+3*nh),
+		d:  make([]float64, nw+3*nh),
+		h:  zeros(1, nh)[0],
+		nx: nx,
+		nh: nh,
+	}
+	for i := 0; i < nw; i++ {
+		l.p[i] = rand.Float64()*0.2 - 0.1
+	}
+	p, d := l.p, l.d
+	l.Wz, l.Wr, l.Wn = carve(&p, nh, nx), carve(&p, nh, nx), carve(&p, nh, nx)
+	l.Uz, l.Ur, l.Un = carve(&p, nh, nh), carve(&p, nh, nh), carve(&p, nh, nh)
+	l.Bz, l.Br, l.Bn = carveVec(&p, nh), carveVec(&p, nh), carveVec(&p, nh)
+	l.dWz, l.dWr, l.dWn = carve(&d, nh, nx), carve(&d, nh, nx), carve(&d, nh, nx)
+	l.dUz, l.dUr, l.dUn = carve(&d, nh, nh), carve(&d, nh, nh), carve(&d, nh, nh)
+	l.dBz, l.dBr, l.dBn = carveVec(&d, nh), carveVec(&d, nh), carveVec(&d, nh)
+	return l
+}
@@ -1,10 +1,47 @@
 package goann
 
-// NewNet nl: number of recurrent layers; eta learning rate
-func NewLSTM(nl int, eta float64) LSTMlayers {
+import "math/rand"
+
+// NewLSTM nx: number of inputs (forcings) per timestep; nh: number of hidden units in each recurrent layer;
+// ny: number of outputs (targets) of the dense output layer having activation act; eta learning rate
+func NewLSTM(nx int, nh []int, ny int, act Activation, eta float64) LSTMlayers {
+	nl := len(nh)
+	layer := make([]LSTM, nl)
+	for k := 0; k < nl; k++ {
+		m := nx
+		if k > 0 {
+			m = nh[k-1] // deep layers are fed by the hidden state of the layer below
+		}
+		layer[k] = newLSTMcell(m, nh[k])
+	}
 	return LSTMlayers{
-		layer: make([]LSTM, nl),
+		layer: layer,
+		head:  newDense(nh[nl-1], ny, act),
+		cache: make([][]lstmCache, nl),
 		eta:   eta,
 		nl:    nl,
 	}
 }
+
+func newLSTMcell(nx, nh int) LSTM {
+	nw := 4 * nh * (nx + nh) // weights are randomized, biases start at zero
+	l := LSTM{
+		p:  make([]float64, nw+4*nh),
+		d:  make([]float64, nw+4*nh),
+		h:  zeros(1, nh)[0],
+		c:  zeros(1, nh)[0],
+		nx: nx,
+		nh: nh,
+	}
+	for i := 0; i < nw; i++ {
+		l.p[i] = rand.Float64()*0.2 - 0.1
+	}
+	p, d := l.p, l.d
+	l.Wf, l.Wg, l.Wi, l.Wo = carve(&p, nh, nx), carve(&p, nh, nx), carve(&p, nh, nx), carve(&p, nh, nx)
+	l.Uf, l.Ug, l.Ui, l.Uo = carve(&p, nh, nh), carve(&p, nh, nh), carve(&p, nh, nh), carve(&p, nh, nh)
+	l.Bf, l.Bg, l.Bi, l.Bo = carveVec(&p, nh), carveVec(&p, nh), carveVec(&p, nh), carveVec(&p, nh)
+	l.dWf, l.dWg, l.dWi, l.dWo = carve(&d, nh, nx), carve(&d, nh, nx), carve(&d, nh, nx), carve(&d, nh, nx)
+	l.dUf, l.dUg, l.dUi, l.dUo = carve(&d, nh, nh), carve(&d, nh, nh), carve(&d, nh, nh), carve(&d, nh, nh)
+	l.dBf, l.dBg, l.dBi, l.dBo = carveVec(&d, nh), carveVec(&d, nh), carveVec(&d, nh), carveVec(&d, nh)
+	return l
+}
@@ -64,6 +64,7 @@ func NewNet(m, n, p, nhl int, eta float64) Network {
 		return Network{
 			nd:  nodes,
 			eta: eta,
+			out: Sigmoid,
 			m:   m,
 			n:   n,
 			p:   p,
@@ -102,6 +103,7 @@ func NewNet(m, n, p, nhl int, eta float64) Network {
 	return Network{
 		nd:  nodes,
 		eta: eta,
+		out: Sigmoid,
 		m:   m,
 		n:   n,
 		p:   p,
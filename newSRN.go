@@ -0,0 +1,58 @@
+package goann
+
+import "math/rand"
+
+// NewElman simple recurrent network where context nodes hold the previous hidden activations.
+// m: number of input nodes; n: number of hidden nodes; p: number of output nodes; eta learning rate
+func NewElman(m, n, p int, eta float64) SRN { return newSRN(m, n, p, n, eta, false) }
+
+// NewJordan simple recurrent network where context nodes hold the previous output activations.
+// m: number of input nodes; n: number of hidden nodes; p: number of output nodes; eta learning rate
+func NewJordan(m, n, p int, eta float64) SRN { return newSRN(m, n, p, p, eta, true) }
+
+// newSRN c: number of context nodes
+func newSRN(m, n, p, c int, eta float64, jordan bool) SRN {
+	init := func() float64 { return .25 * (2.*rand.Float64() - 1.) }
+
+	in, ctx, hid, out := make([]*node, m), make([]*node, c), make([]*node, n), make([]*node, p)
+	for i := 0; i < m; i++ {
+		in[i] = &node{b: nil, f: make([]*weight, n)}
+	}
+	for i := 0; i < c; i++ {
+		ctx[i] = &node{b: nil, f: make([]*weight, n)}
+	}
+	for j := 0; j < n; j++ {
+		hid[j] = &node{b: make([]*weight, m+c), f: make([]*weight, p)}
+	}
+	for k := 0; k < p; k++ {
+		out[k] = &node{b: make([]*weight, n), f: nil}
+	}
+
+	// connectivity
+	for j := 0; j < n; j++ {
+		for i := 0; i < m; i++ { // inputs into hidden layer
+			w := weight{w: init(), b: in[i], f: hid[j]}
+			in[i].f[j] = &w
+			hid[j].b[i] = &w
+		}
+		for i := 0; i < c; i++ { // recurrent edges: context into hidden layer
+			w := weight{w: init(), b: ctx[i], f: hid[j]}
+			ctx[i].f[j] = &w
+			hid[j].b[m+i] = &w
+		}
+		for k := 0; k < p; k++ { // outputs from hidden layer
+			w := weight{w: init(), b: hid[j], f: out[k]}
+			hid[j].f[k] = &w
+			out[k].b[j] = &w
+		}
+	}
+
+	return SRN{
+		in:     in,
+		ctx:    ctx,
+		hid:    hid,
+		out:    out,
+		eta:    eta,
+		jordan: jordan,
+	}
+}
@@ -0,0 +1,96 @@
+package goann
+
+import "math/rand"
+
+// SetOutput sets the activation of the output nodes (default Sigmoid). For instance, Linear
+// outputs are required when the network predicts the parameters of a Distribution.
+func (nn *Network) SetOutput(act Activation) { nn.out = act }
+
+// SetDropout sets the rate at which hidden nodes are dropped when training with TrainLoss (default 0: no dropout)
+func (nn *Network) SetDropout(rate float64) { nn.drop = rate }
+
+// value returns the activation of a node, given its summed input (h)
+func (nn *Network) value(n *node) float64 {
+	switch {
+	case n.b == nil: // input
+		return n.h
+	case n.f == nil: // output
+		return nn.out.f(n.h + n.bias)
+	}
+	return sigmoid(n.h+n.bias) * n.m
+}
+
+// forward propagates through every layer of the graph (nodes are stored in topological order).
+// When dropout is on, hidden nodes are randomly dropped (inverted dropout).
+func (nn *Network) forward(input []float64, dropout bool) []float64 {
+	for _, n := range nn.nd {
+		n.h, n.e, n.m = 0., 0., 1.
+		if dropout && nn.drop > 0. && n.b != nil && n.f != nil {
+			n.m = 0.
+			if rand.Float64() >= nn.drop {
+				n.m = 1. / (1. - nn.drop)
+			}
+		}
+	}
+	for i := 0; i < nn.m; i++ {
+		nn.nd[i].h = input[i]
+	}
+	o := make([]float64, 0, nn.p)
+	for _, n := range nn.nd {
+		a := nn.value(n)
+		if n.f == nil {
+			o = append(o, a)
+		}
+		for _, w := range n.f {
+			w.f.h += w.w * a
+		}
+	}
+	return o
+}
+
+// backward back-propagates dy, the loss gradient w.r.t. the outputs of the last call to forward,
+// accumulating weight and bias diffs
+func (nn *Network) backward(dy []float64) {
+	k := len(dy)
+	for i := len(nn.nd) - 1; i >= nn.m; i-- {
+		n := nn.nd[i]
+		if n.f == nil { // output
+			k--
+			n.e = dy[k] * nn.out.prime(n.h+n.bias)
+		} else {
+			for _, w := range n.f {
+				n.e += w.w * w.f.e
+			}
+			n.e *= sigmoidPrime(n.h+n.bias) * n.m
+		}
+		n.bd += n.e
+		for _, w := range n.b {
+			w.d += n.e * nn.value(w.b)
+		}
+	}
+}
+
+// applyDiff steepest descent, then resets diffs to zero
+func (nn *Network) applyDiff(lr float64) {
+	for _, n := range nn.nd {
+		n.bias -= lr * n.bd
+		n.bd = 0.
+		for _, w := range n.b {
+			w.w -= lr * w.d
+			w.d = 0.
+		}
+	}
+}
+
+// TrainLoss trains a single sample against a loss layer (e.g., a Distribution), returning the loss
+func (nn *Network) TrainLoss(input, trainer []float64, loss Loss) float64 {
+	return nn.TrainLossWeighted(input, trainer, loss, 1.)
+}
+
+// TrainLossWeighted as TrainLoss, where the sample's loss (and gradient) is scaled by wt
+func (nn *Network) TrainLossWeighted(input, trainer []float64, loss Loss, wt float64) float64 {
+	y := nn.forward(input, true)
+	nn.backward(loss.Diff(y, trainer))
+	nn.applyDiff(wt * nn.eta)
+	return wt * loss.Loss(y, trainer)
+}
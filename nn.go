@@ -1,18 +1,26 @@
 package goann
 
+import "math"
+
 type node struct {
 	b, f       []*weight
 	h, e, bias float64
+	bd         float64 // bias diff
+	m          float64 // dropout scale of the activation (0: dropped)
 }
 
 type weight struct {
-	b, f *node
-	w    float64
+	b, f   *node
+	w, d   float64 // weight and its diff (derivative of loss function w.r.t. w)
+	mu, lv float64 // variational mean and log-variance (BayesNetwork)
+	eps    float64 // standard normal draw giving the current sample w = mu + exp(lv/2)*eps
 }
 
 type Network struct {
 	nd      []*node
 	eta     float64
+	out     Activation // output node activation
+	drop    float64    // hidden node dropout rate (TrainLoss and PredictMC)
 	m, n, p int
 }
 
@@ -41,12 +49,18 @@ func (nn *Network) Feed(input []float64) []float64 {
 	o := make([]float64, nn.p)
 	for k := 0; k < nn.p; k++ {
 		kk := nn.m + nn.n + k
-		o[k] = sigmoid(nn.nd[kk].h + nn.nd[kk].bias)
+		o[k] = nn.out.f(nn.nd[kk].h + nn.nd[kk].bias)
 	}
 	return o
 }
 
 func (nn *Network) Train(input, trainer []float64) {
+	nn.TrainWeighted(input, trainer, 1.)
+}
+
+// TrainWeighted as Train, where the sample's gradient contribution is scaled by wt
+func (nn *Network) TrainWeighted(input, trainer []float64, wt float64) {
+	eta := wt * nn.eta
 	// forward propagate
 	nn.reset()
 	for i := 0; i < nn.m; i++ {
@@ -64,15 +78,18 @@ func (nn *Network) Train(input, trainer []float64) {
 
 	// back propagate errors
 	for k := 0; k < nn.p; k++ {
+		if math.IsNaN(trainer[k]) { // missing: not in loss
+			continue
+		}
 		n := nn.nd[nn.m+nn.n+k]
-		y := sigmoid(n.h + n.bias)
-		yp := sigmoidPrime(n.h + n.bias)
+		y := nn.out.f(n.h + n.bias)
+		yp := nn.out.prime(n.h + n.bias)
 		e := (trainer[k] - y)
 		for _, w := range n.b {
 			w.b.e += w.w * e
-			w.w += nn.eta * e * yp * sigmoid(w.b.h+w.b.bias)
+			w.w += eta * e * yp * sigmoid(w.b.h+w.b.bias)
 		}
-		n.bias += nn.eta * e * yp
+		n.bias += eta * e * yp
 	}
 
 	for j := nn.n - 1; j >= 0; j-- {
@@ -80,13 +97,19 @@ func (nn *Network) Train(input, trainer []float64) {
 		yp := sigmoidPrime(n.h + n.bias)
 		for _, w := range n.b {
 			w.b.e += w.w * n.e
-			w.w += nn.eta * n.e * yp * w.b.h // only for w.b.h = inputs, otherwise, for deep networks, use sigmoid(w.b.h+w.b.bias)
+			w.w += eta * n.e * yp * w.b.h // only for w.b.h = inputs, otherwise, for deep networks, use sigmoid(w.b.h+w.b.bias)
 		}
-		n.bias += nn.eta * n.e * yp
+		n.bias += eta * n.e * yp
 	}
 }
 
 func (nn *Network) TrainNoBias(input, trainer []float64) {
+	nn.TrainNoBiasWeighted(input, trainer, 1.)
+}
+
+// TrainNoBiasWeighted as TrainNoBias, where the sample's gradient contribution is scaled by wt
+func (nn *Network) TrainNoBiasWeighted(input, trainer []float64, wt float64) {
+	eta := wt * nn.eta
 	// forward propagate
 	nn.reset()
 	for i := 0; i < nn.m; i++ {
@@ -104,13 +127,16 @@ func (nn *Network) TrainNoBias(input, trainer []float64) {
 
 	// back propagate errors
 	for k := 0; k < nn.p; k++ {
+		if math.IsNaN(trainer[k]) { // missing: not in loss
+			continue
+		}
 		n := nn.nd[nn.m+nn.n+k]
-		y := sigmoid(n.h)
-		yp := sigmoidPrime(n.h)
+		y := nn.out.f(n.h)
+		yp := nn.out.prime(n.h)
 		e := (trainer[k] - y)
 		for _, w := range n.b {
 			w.b.e += w.w * e
-			w.w += nn.eta * e * yp * sigmoid(w.b.h)
+			w.w += eta * e * yp * sigmoid(w.b.h)
 		}
 	}
 
@@ -119,7 +145,7 @@ func (nn *Network) TrainNoBias(input, trainer []float64) {
 		yp := sigmoidPrime(n.h)
 		for _, w := range n.b {
 			w.b.e += w.w * n.e
-			w.w += nn.eta * n.e * yp * w.b.h // only for w.b.h = inputs, otherwise, for deep networks, use sigmoid(w.b.h)
+			w.w += eta * n.e * yp * w.b.h // only for w.b.h = inputs, otherwise, for deep networks, use sigmoid(w.b.h)
 		}
 	}
 }
@@ -0,0 +1,194 @@
+package goann
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Flag hydrometric data quality flag
+type Flag int
+
+const (
+	NoFlag              Flag = iota // ""
+	Estimate                        // "estimate"
+	IceConditions                   // "ice_conditions"
+	Partial                         // "partial"
+	RealtimeUncorrected             // "realtime_uncorrected"
+	OtherFlag                       // any other (unrecognized) flag
+)
+
+var flagNames = map[Flag]string{
+	NoFlag:              "",
+	Estimate:            "estimate",
+	IceConditions:       "ice_conditions",
+	Partial:             "partial",
+	RealtimeUncorrected: "realtime_uncorrected",
+	OtherFlag:           "other",
+}
+
+func (f Flag) String() string { return flagNames[f] }
+
+// ParseFlag returns the Flag of its text representation (e.g., "ice_conditions")
+func ParseFlag(s string) Flag {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for f, n := range flagNames {
+		if n == s && f != OtherFlag {
+			return f
+		}
+	}
+	return OtherFlag
+}
+
+// Table is a daily time-series table: every numeric column is addressable by its name,
+// missing values are NaN
+type Table struct {
+	Time    []time.Time
+	Columns []string    // numeric column names (e.g., "Flow", "Tx", "Tn", "Rf", "Sf", "Sm", "Pa")
+	Data    [][]float64 // [column][timestep]
+	Flag    []Flag      // [timestep] quality flag (NoFlag when the table has no flag column)
+}
+
+// Len returns the number of timesteps
+func (t *Table) Len() int { return len(t.Time) }
+
+// Column returns the series of the named column
+func (t *Table) Column(name string) ([]float64, error) {
+	for i, c := range t.Columns {
+		if c == name {
+			return t.Data[i], nil
+		}
+	}
+	return nil, fmt.Errorf("column %q not found", name)
+}
+
+// Rows returns, for every timestep, the vector of the named columns, e.g., Rows("Rf", "Sm", "Tx")
+// gives a sequence of forcing vectors
+func (t *Table) Rows(names ...string) ([][]float64, error) {
+	cols := make([][]float64, len(names))
+	for k, n := range names {
+		c, err := t.Column(n)
+		if err != nil {
+			return nil, err
+		}
+		cols[k] = c
+	}
+	o := make([][]float64, t.Len())
+	for j := range o {
+		o[j] = make([]float64, len(names))
+		for k, c := range cols {
+			o[j][k] = c[j]
+		}
+	}
+	return o, nil
+}
+
+// Join returns a copy of t, dated by t, with the columns of u appended, where dates absent from u
+// are NaN. Columns of u already found in t are ignored (t takes precedence), as are u's flags.
+func (t *Table) Join(u *Table) *Table {
+	idx := make(map[time.Time]int, u.Len())
+	for i, d := range u.Time {
+		idx[d] = i
+	}
+	o := &Table{Time: t.Time, Columns: append([]string(nil), t.Columns...), Data: append([][]float64(nil), t.Data...), Flag: t.Flag}
+	for k, c := range u.Columns {
+		if _, err := t.Column(c); err == nil {
+			continue
+		}
+		v := make([]float64, t.Len())
+		for j, d := range t.Time {
+			if i, ok := idx[d]; ok {
+				v[j] = u.Data[k][i]
+			} else {
+				v[j] = math.NaN()
+			}
+		}
+		o.Columns = append(o.Columns, c)
+		o.Data = append(o.Data, v)
+	}
+	return o
+}
+
+// ReadOWRC reads an Oak Ridges Moraine Groundwater Program (OWRC) hydrometric CSV:
+// "Date","Flow","Flag","Tx","Tn","Rf","Sf","Sm","Pa"
+func ReadOWRC(fp string) (*Table, error) {
+	f, err := os.Open(fp)
+	if err != nil {
+		return nil, fmt.Errorf("ReadOWRC: %v", err)
+	}
+	defer f.Close()
+	t, err := ParseOWRC(f)
+	if err != nil {
+		return nil, fmt.Errorf("ReadOWRC %s: %v", fp, err)
+	}
+	return t, nil
+}
+
+// ParseOWRC reads an OWRC-format table: a header row, a "Date" column (yyyy-mm-dd), an optional
+// "Flag" column and any number of numeric columns, where "NA", "NaN" or blank values are missing
+func ParseOWRC(r io.Reader) (*Table, error) {
+	cr := csv.NewReader(r)
+	hdr, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("header read fail: %v", err)
+	}
+	idate, iflag, icol := -1, -1, []int{}
+	t := &Table{}
+	for i, h := range hdr {
+		switch h = strings.TrimSpace(h); h {
+		case "Date":
+			idate = i
+		case "Flag":
+			iflag = i
+		default:
+			t.Columns = append(t.Columns, h)
+			icol = append(icol, i)
+		}
+	}
+	if idate < 0 {
+		return nil, fmt.Errorf("no Date column")
+	}
+	t.Data = make([][]float64, len(icol))
+
+	for ln := 2; ; ln++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		d, err := time.Parse("2006-01-02", rec[idate])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: date read fail: %v", ln, err)
+		}
+		t.Time = append(t.Time, d)
+		if iflag >= 0 {
+			t.Flag = append(t.Flag, ParseFlag(rec[iflag]))
+		} else {
+			t.Flag = append(t.Flag, NoFlag)
+		}
+		for k, i := range icol {
+			v, err := parseValue(rec[i])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: column %s: %v", ln, t.Columns[k], err)
+			}
+			t.Data[k] = append(t.Data[k], v)
+		}
+	}
+	return t, nil
+}
+
+// parseValue returns NaN for missing values
+func parseValue(s string) (float64, error) {
+	switch s = strings.TrimSpace(s); s {
+	case "", "NA", "NaN", "nan":
+		return math.NaN(), nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,77 @@
+package goann
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const owrcFixture = `"Date","Flow","Flag","Tx","Tn","Rf","Sf","Sm","Pa"
+2001-01-01,1.25,"ice_conditions",-2.5,-10.1,0,3.2,0,98.1
+2001-01-02,NA,,-1.0,-8.0,1.5,0,0.4,98.3
+2001-01-03,1.10,"estimate",0.5,-4.2,NA,0,2.1,98.0
+`
+
+func TestReadOWRC(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "02EC018.csv")
+	if err := os.WriteFile(fp, []byte(owrcFixture), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tb, err := ReadOWRC(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tb.Len() != 3 || !tb.Time[2].Equal(time.Date(2001, 1, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("read %d timesteps %v", tb.Len(), tb.Time)
+	}
+	if want := []string{"Flow", "Tx", "Tn", "Rf", "Sf", "Sm", "Pa"}; strings.Join(tb.Columns, ",") != strings.Join(want, ",") {
+		t.Errorf("columns %v, expecting %v", tb.Columns, want)
+	}
+	if tb.Flag[0] != IceConditions || tb.Flag[1] != NoFlag || tb.Flag[2] != Estimate {
+		t.Errorf("flags %v", tb.Flag)
+	}
+	q, _ := tb.Column("Flow")
+	if q[0] != 1.25 || !math.IsNaN(q[1]) {
+		t.Errorf("Flow %v", q)
+	}
+	x, err := tb.Rows("Rf", "Tx")
+	if err != nil || len(x) != 3 || x[1][0] != 1.5 || x[1][1] != -1. || !math.IsNaN(x[2][0]) {
+		t.Errorf("Rows %v %v", x, err)
+	}
+	if _, err := tb.Column("Pr"); err == nil {
+		t.Error("missing column found")
+	}
+}
+
+func TestParseOWRC(t *testing.T) {
+	for name, s := range map[string]string{
+		"no Date": "Flow\n1.0\n",
+		"date":    "Date,Flow\n2001-13-01,1.0\n",
+		"value":   "Date,Flow\n2001-01-01,abc\n",
+	} {
+		if _, err := ParseOWRC(strings.NewReader(s)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+	tb, err := ParseOWRC(strings.NewReader("Date,Flow\n2001-01-01,2\n"))
+	if err != nil || tb.Flag[0] != NoFlag {
+		t.Errorf("table without flags: %v", err)
+	}
+	if ParseFlag(" Partial ") != Partial || ParseFlag("backwater") != OtherFlag {
+		t.Error("ParseFlag")
+	}
+}
+
+func TestTableJoin(t *testing.T) {
+	d := func(i int) time.Time { return time.Date(2001, 1, i, 0, 0, 0, 0, time.UTC) }
+	q := &Table{Time: []time.Time{d(1), d(2)}, Columns: []string{"Flow"}, Data: [][]float64{{1., 2.}}, Flag: []Flag{NoFlag, Estimate}}
+	m := &Table{Time: []time.Time{d(2), d(3)}, Columns: []string{"Flow", "Tx"}, Data: [][]float64{{9., 9.}, {5., 6.}}}
+	j := q.Join(m)
+	x, err := j.Rows("Flow", "Tx")
+	if err != nil || len(j.Columns) != 2 || x[1][0] != 2. || !math.IsNaN(x[0][1]) || x[1][1] != 5. || j.Flag[1] != Estimate {
+		t.Errorf("joined %v %v %v", j.Columns, x, err)
+	}
+}
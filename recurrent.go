@@ -0,0 +1,128 @@
+package goann
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+)
+
+// Recurrent is implemented by every recurrent network (LSTMlayers, LSTMnetwork, GRUlayers, EALSTM), such that
+// trainers, benchmarks and serialization can treat any recurrent cell the same way.
+type Recurrent interface {
+	Reset()                             // return the state to rest
+	Step(x []float64) []float64         // advance one timestep from the current state (inference, weights untouched)
+	Forward(xs [][]float64) [][]float64 // propagate a sequence from the current state, saving states for Backward
+	Backward(dy [][]float64)            // back-propagate loss gradients w.r.t. the predictions of Forward (nil: not in loss), accumulating diffs
+	Params() []Param                    // trainable parameters and their diffs
+}
+
+var (
+	_ Recurrent = (*LSTMlayers)(nil)
+	_ Recurrent = (*LSTMnetwork)(nil)
+	_ Recurrent = (*GRUlayers)(nil)
+	_ Recurrent = (*EALSTM)(nil)
+)
+
+// Param is a flat view of a set of trainable weights (W) and the derivative of the loss function w.r.t. them (D)
+type Param struct{ W, D []float64 }
+
+// carve slices an nr x nc matrix of row views off the front of buffer a
+func carve(a *[]float64, nr, nc int) [][]float64 {
+	o := rows((*a)[:nr*nc], nr, nc)
+	*a = (*a)[nr*nc:]
+	return o
+}
+
+// carveVec slices a vector of length n off the front of buffer a
+func carveVec(a *[]float64, n int) []float64 {
+	o := (*a)[:n:n]
+	*a = (*a)[n:]
+	return o
+}
+
+// ApplyDiff steepest descent on all parameters, then resets diffs to zero
+func ApplyDiff(ps []Param, lr float64) {
+	for _, p := range ps {
+		for i, d := range p.D {
+			p.W[i] -= lr * d
+			p.D[i] = 0.
+		}
+	}
+}
+
+// TrainSequence trains a recurrent network on a sequence of inputs (xs) and target vectors (ys),
+// starting from rest. Returns the loss summed over the sequence. Steps scale with loss.Diff, e.g.,
+// MSE back-propagates 2(pred-label), twice the error back-propagated by LSTMlayers.Train.
+func TrainSequence(r Recurrent, xs, ys [][]float64, loss Loss, lr float64) float64 {
+	return trainSequence(r, xs, ys, nil, loss, lr)
+}
+
+// TrainSequenceWeighted as TrainSequence, where the loss of every timestep is scaled by its weight (nil: 1).
+// Returns an error when the input, target and weight lengths differ.
+func TrainSequenceWeighted(r Recurrent, xs, ys [][]float64, wts []float64, loss Loss, lr float64) (float64, error) {
+	if len(xs) != len(ys) {
+		return 0., fmt.Errorf("TrainSequence: input (%d) and target (%d) lengths differ", len(xs), len(ys))
+	}
+	if wts != nil && len(wts) != len(ys) {
+		return 0., fmt.Errorf("TrainSequence: target (%d) and weight (%d) lengths differ", len(ys), len(wts))
+	}
+	return trainSequence(r, xs, ys, wts, loss, lr), nil
+}
+
+func trainSequence(r Recurrent, xs, ys [][]float64, wts []float64, loss Loss, lr float64) float64 {
+	r.Reset()
+	ypred := r.Forward(xs)
+	l, dy := 0., make([][]float64, len(ys))
+	for j, y := range ys {
+		w := weightAt(wts, j)
+		l += w * loss.Loss(ypred[j], y)
+		dy[j] = loss.Diff(ypred[j], y)
+		for k := range dy[j] {
+			dy[j][k] *= w
+		}
+	}
+	r.Backward(dy)
+	ApplyDiff(r.Params(), lr)
+	return l
+}
+
+// SaveParams writes the trainable parameters of a recurrent network to a gob file
+func SaveParams(r Recurrent, fp string) error {
+	ps := r.Params()
+	w := make([][]float64, len(ps))
+	for i, p := range ps {
+		w[i] = p.W
+	}
+	f, err := os.Create(fp)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return gob.NewEncoder(f).Encode(w)
+}
+
+// LoadParams reads parameters written by SaveParams into a network of the same structure
+func LoadParams(r Recurrent, fp string) error {
+	f, err := os.Open(fp)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var w [][]float64
+	if err := gob.NewDecoder(f).Decode(&w); err != nil {
+		return err
+	}
+	ps := r.Params()
+	if len(w) != len(ps) {
+		return fmt.Errorf("LoadParams: %d parameter sets read, network has %d", len(w), len(ps))
+	}
+	for i, p := range ps {
+		if len(w[i]) != len(p.W) {
+			return fmt.Errorf("LoadParams: parameter set %d has %d weights, network has %d", i, len(w[i]), len(p.W))
+		}
+	}
+	for i, p := range ps {
+		copy(p.W, w[i])
+	}
+	return nil
+}
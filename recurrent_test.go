@@ -0,0 +1,113 @@
+package goann
+
+import (
+	"math"
+	"testing"
+)
+
+// seq returns a deterministic sequence of n vectors of length m, of amplitude a
+func seq(n, m int, a, phase float64) [][]float64 {
+	o := make([][]float64, n)
+	for t := range o {
+		o[t] = make([]float64, m)
+		for k := range o[t] {
+			o[t][k] = a * math.Sin(phase+1.3*float64(t)+.7*float64(k))
+		}
+	}
+	return o
+}
+
+// gradCheck compares the parameter diffs back-propagated by a recurrent network against central
+// differences of the loss summed over the sequence (nil targets are skipped)
+func gradCheck(t *testing.T, name string, r Recurrent, xs, ys [][]float64, loss Loss) {
+	t.Helper()
+	lossf := func() float64 {
+		r.Reset()
+		yp, l := r.Forward(xs), 0.
+		for j, y := range ys {
+			if y != nil {
+				l += loss.Loss(yp[j], y)
+			}
+		}
+		return l
+	}
+	r.Reset()
+	yp := r.Forward(xs)
+	dy := make([][]float64, len(ys))
+	for j, y := range ys {
+		if y != nil {
+			dy[j] = loss.Diff(yp[j], y)
+		} else {
+			dy[j] = make([]float64, len(yp[j]))
+		}
+	}
+	r.Backward(dy)
+
+	const h = 1e-6
+	nbad := 0
+	for ip, p := range r.Params() {
+		d := append([]float64(nil), p.D...)
+		for i := range p.D {
+			p.D[i] = 0.
+		}
+		for i, w := range p.W {
+			p.W[i] = w + h
+			lp := lossf()
+			p.W[i] = w - h
+			lm := lossf()
+			p.W[i] = w
+			if num := (lp - lm) / 2. / h; math.Abs(num-d[i]) > 1e-5*math.Max(1., math.Abs(num)) {
+				if nbad++; nbad <= 5 {
+					t.Errorf("%s: parameter set %d [%d]: numerical %g, back-propagated %g", name, ip, i, num, d[i])
+				}
+			}
+		}
+	}
+}
+
+func TestLSTMlayersGradient(t *testing.T) {
+	xs, ys := seq(6, 3, 1., 0.), seq(6, 2, .5, 1.)
+	ys[1] = nil // missing target
+	ls := NewLSTM(3, []int{4, 3}, 2, Tanh, .1)
+	gradCheck(t, "LSTMlayers", &ls, xs, ys, MSE{})
+}
+
+func TestGRUlayersGradient(t *testing.T) {
+	xs, ys := seq(6, 3, 1., 0.), seq(6, 2, .5, 1.)
+	gs := NewGRU(3, []int{4, 3}, 2, Linear, .1)
+	gradCheck(t, "GRUlayers", &gs, xs, ys, MSE{})
+}
+
+func TestEALSTMGradient(t *testing.T) {
+	xs, ys := seq(6, 3, 1., 0.), seq(6, 2, .5, 1.)
+	ea := NewEALSTM(3, 2, 4, 2, Linear, .1)
+	if err := ea.SetStatic([]float64{.3, -.7}); err != nil {
+		t.Fatal(err)
+	}
+	gradCheck(t, "EALSTM", &ea, xs, ys, MSE{})
+}
+
+func TestLSTMnetworkGradient(t *testing.T) {
+	xs, ys := seq(6, 3, 1., 0.), seq(6, 2, .5, 1.)
+	lw := NewLSTMnetwork(NewLSTMparam(4, 3), 2, Linear)
+	gradCheck(t, "LSTMnetwork", &lw, xs, ys, MSE{})
+}
+
+func TestTrainSequenceWeighted(t *testing.T) {
+	xs, ys := seq(4, 2, 1., 0.), seq(4, 1, .5, 1.)
+	ls := NewLSTM(2, []int{3}, 1, Linear, .1)
+	if _, err := TrainSequenceWeighted(&ls, xs, ys[:3], nil, MSE{}, 0.); err == nil {
+		t.Error("mismatched targets accepted")
+	}
+	if _, err := TrainSequenceWeighted(&ls, xs, ys, []float64{1.}, MSE{}, 0.); err == nil {
+		t.Error("mismatched weights accepted")
+	}
+	l1 := TrainSequence(&ls, xs, ys, MSE{}, 0.)
+	l2, err := TrainSequenceWeighted(&ls, xs, ys, []float64{2., 2., 2., 2.}, MSE{}, 0.)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(l2-2.*l1) > 1e-12 {
+		t.Errorf("weighted loss %g, expecting %g", l2, 2.*l1)
+	}
+}
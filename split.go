@@ -0,0 +1,200 @@
+package goann
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Split partitions the timesteps of a record (indices into its timestamps) into training,
+// validation and testing sets. Sets may be empty.
+type Split struct {
+	Train, Valid, Test []int
+}
+
+// WaterYear returns the hydrologic water year of t, beginning on the first day of startMonth
+// (e.g., time.October) and labelled by the calendar year in which it ends
+func WaterYear(t time.Time, startMonth time.Month) int {
+	if startMonth == time.January || t.Month() < startMonth {
+		return t.Year()
+	}
+	return t.Year() + 1
+}
+
+// SplitDate splits a chronological record by date: training before validStart, validation from
+// validStart to testStart and testing from testStart onward. Training timesteps within gap of
+// held-out timesteps are excluded (a buffer), such that lagged inputs (e.g., antecedent flows)
+// of one set do not overlap the targets of another (gap 0: no buffer).
+func SplitDate(ts []time.Time, validStart, testStart time.Time, gap int) Split {
+	set := make([]int, len(ts)) // 0: train; 1: valid; 2: test
+	for i, t := range ts {
+		switch {
+		case !t.Before(testStart):
+			set[i] = 2
+		case !t.Before(validStart):
+			set[i] = 1
+		}
+	}
+	return partition(set, gap)
+}
+
+// SplitWaterYear assigns whole water years (see WaterYear) to the validation and testing sets,
+// all remaining years are used for training, less a buffer of gap timesteps about held-out years.
+func SplitWaterYear(ts []time.Time, startMonth time.Month, validYears, testYears []int, gap int) Split {
+	in := func(y int, ys []int) bool {
+		for _, v := range ys {
+			if v == y {
+				return true
+			}
+		}
+		return false
+	}
+	set := make([]int, len(ts))
+	for i, t := range ts {
+		wy := WaterYear(t, startMonth)
+		switch {
+		case in(wy, testYears):
+			set[i] = 2
+		case in(wy, validYears):
+			set[i] = 1
+		}
+	}
+	return partition(set, gap)
+}
+
+// BlockedKFold returns k splits of a record cut into k contiguous blocks, each block in turn held
+// out for testing, with gap timesteps excluded from training on either side of the held-out block.
+// Returns an error unless 1 <= k <= n, such that every block holds at least one timestep.
+func BlockedKFold(n, k, gap int) ([]Split, error) {
+	if k < 1 || k > n {
+		return nil, fmt.Errorf("BlockedKFold: %d folds of %d timesteps, expecting 1 <= k <= n", k, n)
+	}
+	o := make([]Split, k)
+	for f := 0; f < k; f++ {
+		set := make([]int, n)
+		for i := f * n / k; i < (f+1)*n/k; i++ {
+			set[i] = 2
+		}
+		o[f] = partition(set, gap)
+	}
+	return o, nil
+}
+
+// LeaveOneYearOut returns a split for every water year in the record, holding it out for testing,
+// with gap timesteps excluded from training on either side of the held-out year
+func LeaveOneYearOut(ts []time.Time, startMonth time.Month, gap int) []Split {
+	yrs := waterYears(ts, startMonth)
+	o := make([]Split, len(yrs))
+	for i, y := range yrs {
+		o[i] = SplitWaterYear(ts, startMonth, nil, []int{y}, gap)
+	}
+	return o
+}
+
+// DifferentialSplit is the differential split-sample test of Klemeš (1986): water years are
+// ranked by the annual mean of v (e.g., precipitation or flow, missing values skipped), then the
+// model is trained on the dry half and tested on the wet half, and vice versa. Water years having
+// no value of v cannot be ranked and are left out of both splits. Returns the dry-to-wet split
+// followed by the wet-to-dry split, or an error when v and ts differ in length.
+func DifferentialSplit(ts []time.Time, v []float64, startMonth time.Month, gap int) ([2]Split, error) {
+	if len(v) != len(ts) {
+		return [2]Split{}, fmt.Errorf("DifferentialSplit: %d timestamps and %d values", len(ts), len(v))
+	}
+	sum, cnt := map[int]float64{}, map[int]float64{}
+	for i, t := range ts {
+		if !math.IsNaN(v[i]) {
+			wy := WaterYear(t, startMonth)
+			sum[wy] += v[i]
+			cnt[wy]++
+		}
+	}
+	var yrs []int // years that can be ranked
+	for _, y := range waterYears(ts, startMonth) {
+		if cnt[y] > 0. {
+			yrs = append(yrs, y)
+		}
+	}
+	sort.SliceStable(yrs, func(i, j int) bool { return sum[yrs[i]]/cnt[yrs[i]] < sum[yrs[j]]/cnt[yrs[j]] })
+	dry, wet := yrs[:len(yrs)/2], yrs[len(yrs)/2:]
+	o := [2]Split{SplitWaterYear(ts, startMonth, nil, wet, gap), SplitWaterYear(ts, startMonth, nil, dry, gap)}
+	for k := range o { // drop unranked years from training
+		tr := o[k].Train[:0]
+		for _, i := range o[k].Train {
+			if cnt[WaterYear(ts[i], startMonth)] > 0. {
+				tr = append(tr, i)
+			}
+		}
+		o[k].Train = tr
+	}
+	return o, nil
+}
+
+// waterYears returns the distinct water years of a record, in chronological order
+func waterYears(ts []time.Time, startMonth time.Month) []int {
+	var o []int
+	for _, t := range ts {
+		if wy := WaterYear(t, startMonth); len(o) == 0 || o[len(o)-1] != wy {
+			o = append(o, wy)
+		}
+	}
+	return o
+}
+
+// partition converts a set label per timestep (0: train; 1: valid; 2: test) into a Split, dropping
+// training timesteps within gap of held-out (validation or testing) timesteps
+func partition(set []int, gap int) Split {
+	n := len(set)
+	drop := make([]bool, n)
+	for i := 1; i < n; i++ {
+		if (set[i] == 0) == (set[i-1] == 0) {
+			continue
+		}
+		for j := i - gap; j < i+gap; j++ {
+			if j >= 0 && j < n && set[j] == 0 {
+				drop[j] = true
+			}
+		}
+	}
+	var s Split
+	for i, k := range set {
+		if drop[i] {
+			continue
+		}
+		switch k {
+		case 0:
+			s.Train = append(s.Train, i)
+		case 1:
+			s.Valid = append(s.Valid, i)
+		case 2:
+			s.Test = append(s.Test, i)
+		}
+	}
+	return s
+}
+
+// Subset returns the rows of x at indices idx (e.g., Split.Train)
+func Subset(x [][]float64, idx []int) [][]float64 {
+	o := make([][]float64, len(idx))
+	for i, j := range idx {
+		o[i] = x[j]
+	}
+	return o
+}
+
+// MaskTargets returns a copy of trainer (sequence of target vectors) where every timestep not in
+// idx is set missing (NaN). Recurrent models are thereby trained (or evaluated) on the full,
+// continuous record, with only the targets of a set entering the loss.
+func MaskTargets(trainer [][]float64, idx []int) [][]float64 {
+	o := make([][]float64, len(trainer))
+	for t, y := range trainer {
+		o[t] = make([]float64, len(y))
+		for k := range o[t] {
+			o[t][k] = math.NaN()
+		}
+	}
+	for _, t := range idx {
+		copy(o[t], trainer[t])
+	}
+	return o
+}
@@ -0,0 +1,147 @@
+package goann
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// days returns n daily timestamps from the given date
+func days(y int, m time.Month, d, n int) []time.Time {
+	o := make([]time.Time, n)
+	for i := range o {
+		o[i] = time.Date(y, m, d+i, 0, 0, 0, 0, time.UTC)
+	}
+	return o
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestWaterYear(t *testing.T) {
+	for _, c := range []struct {
+		t     time.Time
+		start time.Month
+		want  int
+	}{
+		{time.Date(2000, 9, 30, 0, 0, 0, 0, time.UTC), time.October, 2000},
+		{time.Date(2000, 10, 1, 0, 0, 0, 0, time.UTC), time.October, 2001},
+		{time.Date(2000, 12, 31, 0, 0, 0, 0, time.UTC), time.January, 2000},
+	} {
+		if wy := WaterYear(c.t, c.start); wy != c.want {
+			t.Errorf("WaterYear(%s, %s) = %d, expecting %d", c.t.Format("2006-01-02"), c.start, wy, c.want)
+		}
+	}
+}
+
+func TestSplitDate(t *testing.T) {
+	ts := days(2000, time.January, 1, 10)
+	s := SplitDate(ts, ts[5], ts[8], 1)
+	if !equalInts(s.Train, []int{0, 1, 2, 3}) || !equalInts(s.Valid, []int{5, 6, 7}) || !equalInts(s.Test, []int{8, 9}) {
+		t.Errorf("gap 1: %+v", s) // timestep 4 buffers the validation set
+	}
+	s = SplitDate(ts, ts[5], ts[8], 0)
+	if len(s.Train) != 5 {
+		t.Errorf("gap 0: %+v", s)
+	}
+}
+
+func TestLeaveOneYearOut(t *testing.T) {
+	ts := days(2000, time.September, 29, 4*365) // water years 2000 (2 days) to 2004
+	ss := LeaveOneYearOut(ts, time.October, 0)
+	if len(ss) != 5 {
+		t.Fatalf("%d splits, expecting one per water year", len(ss))
+	}
+	for k, s := range ss {
+		wy := 2000 + k
+		if len(s.Train)+len(s.Test) != len(ts) || len(s.Valid) != 0 {
+			t.Errorf("water year %d: %d train, %d test of %d", wy, len(s.Train), len(s.Test), len(ts))
+		}
+		for _, i := range s.Test {
+			if WaterYear(ts[i], time.October) != wy {
+				t.Errorf("water year %d: test holds %s", wy, ts[i].Format("2006-01-02"))
+				break
+			}
+		}
+	}
+	if ss = LeaveOneYearOut(ts, time.October, 3); len(ss[2].Train) != len(ts)-len(ss[2].Test)-6 {
+		t.Errorf("gap 3: %d train timesteps, expecting a buffer of 3 either side", len(ss[2].Train))
+	}
+}
+
+func TestDifferentialSplit(t *testing.T) {
+	ts := days(2000, time.January, 1, 6*366)
+	ts = ts[:len(ts)-6] // calendar years 2000 to 2005
+	wet := map[int]bool{2001: true, 2003: true, 2004: true}
+	v := make([]float64, len(ts))
+	for i, d := range ts {
+		switch {
+		case d.Year() == 2002:
+			v[i] = math.NaN() // unranked
+		case wet[d.Year()]:
+			v[i] = 10.
+		default:
+			v[i] = 1.
+		}
+	}
+	if _, err := DifferentialSplit(ts, v[1:], time.January, 0); err == nil {
+		t.Error("mismatched lengths accepted")
+	}
+	ss, err := DifferentialSplit(ts, v, time.January, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, s := range ss {
+		tested := map[int]bool{}
+		for _, i := range s.Test {
+			tested[ts[i].Year()] = true
+		}
+		for _, i := range append(s.Train, s.Test...) {
+			if ts[i].Year() == 2002 {
+				t.Fatalf("split %d: unranked year 2002 used", k)
+			}
+		}
+		for _, i := range s.Train {
+			if tested[ts[i].Year()] {
+				t.Fatalf("split %d: year %d both trained and tested", k, ts[i].Year())
+			}
+		}
+		if k == 0 && !(tested[2003] && tested[2004] && len(tested) == 3) {
+			t.Errorf("dry-to-wet split tests %v, expecting the wet half", tested)
+		}
+	}
+}
+
+func TestBlockedKFold(t *testing.T) {
+	ss, err := BlockedKFold(10, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalInts(ss[1].Test, []int{3, 4, 5}) || !equalInts(ss[1].Train, []int{0, 1, 7, 8, 9}) {
+		t.Errorf("fold 1: %+v", ss[1])
+	}
+	for _, k := range []int{-1, 0, 11} {
+		if _, err := BlockedKFold(10, k, 0); err == nil {
+			t.Errorf("k=%d of 10 accepted", k)
+		}
+	}
+}
+
+func TestMaskTargets(t *testing.T) {
+	y := MaskTargets([][]float64{{1.}, {2.}, {3.}}, []int{1})
+	if !math.IsNaN(y[0][0]) || y[1][0] != 2. || !math.IsNaN(y[2][0]) {
+		t.Errorf("masked %v", y)
+	}
+	if x := Subset([][]float64{{1.}, {2.}, {3.}}, []int{0, 2}); len(x) != 2 || x[1][0] != 3. {
+		t.Errorf("subset %v", x)
+	}
+}
@@ -0,0 +1,190 @@
+package goann
+
+import (
+	"fmt"
+	"math"
+)
+
+// SRN simple recurrent network built from the explicit graph: inputs and context nodes feed a
+// sigmoidal hidden layer, which feeds the outputs. Context nodes carry activations between
+// timesteps: hidden activations for an Elman network, output activations for a Jordan network.
+type SRN struct {
+	in, ctx, hid, out []*node
+	eta               float64
+	jordan            bool
+	trunc             int // BPTT truncation length (<=0: full sequence)
+}
+
+// TruncateBPTT limits back-propagation through time to blocks of n timesteps (n<=0: no truncation)
+func (sn *SRN) TruncateBPTT(n int) { sn.trunc = n }
+
+// Reset returns context nodes to rest
+func (sn *SRN) Reset() {
+	for _, c := range sn.ctx {
+		c.h = 0.
+	}
+}
+
+// feed forward propagates a single timestep from the current context, returning
+// hidden and output activations. Context nodes are not updated.
+func (sn *SRN) feed(input []float64) (a, y []float64) {
+	for _, n := range sn.hid {
+		n.h = 0.
+	}
+	for _, n := range sn.out {
+		n.h = 0.
+	}
+	for i, n := range sn.in {
+		n.h = input[i]
+		for _, w := range n.f {
+			w.f.h += w.w * n.h
+		}
+	}
+	for _, n := range sn.ctx {
+		for _, w := range n.f {
+			w.f.h += w.w * n.h
+		}
+	}
+	a = make([]float64, len(sn.hid))
+	for j, n := range sn.hid {
+		a[j] = sigmoid(n.h + n.bias)
+		for _, w := range n.f {
+			w.f.h += w.w * a[j]
+		}
+	}
+	y = make([]float64, len(sn.out))
+	for k, n := range sn.out {
+		y[k] = sigmoid(n.h + n.bias)
+	}
+	return
+}
+
+// context returns the activations carried to the next timestep
+func (sn *SRN) context(a, y []float64) []float64 {
+	if sn.jordan {
+		return y
+	}
+	return a
+}
+
+// Step advances the network one timestep, returning its prediction
+func (sn *SRN) Step(input []float64) []float64 {
+	a, y := sn.feed(input)
+	for i, v := range sn.context(a, y) {
+		sn.ctx[i].h = v
+	}
+	return y
+}
+
+// Predict runs the network over an input sequence, starting from rest
+func (sn *SRN) Predict(input [][]float64) [][]float64 {
+	sn.Reset()
+	o := make([][]float64, len(input))
+	for t, x := range input {
+		o[t] = sn.Step(x)
+	}
+	return o
+}
+
+// Train forward propagates an input sequence from rest, then back-propagates errors through time
+// (along the recurrent context edges) before updating weights. Returns the sum of squared errors.
+func (sn *SRN) Train(input, trainer [][]float64) float64 {
+	return sn.train(input, trainer, nil)
+}
+
+// TrainWeighted as Train, where the squared errors of every timestep are scaled by its weight (nil: 1).
+// Returns an error when the input, trainer and weight lengths differ.
+func (sn *SRN) TrainWeighted(input, trainer [][]float64, wts []float64) (float64, error) {
+	if len(trainer) != len(input) {
+		return 0., fmt.Errorf("TrainWeighted: input (%d) and trainer (%d) lengths differ", len(input), len(trainer))
+	}
+	if wts != nil && len(wts) != len(input) {
+		return 0., fmt.Errorf("TrainWeighted: input (%d) and weight (%d) lengths differ", len(input), len(wts))
+	}
+	return sn.train(input, trainer, wts), nil
+}
+
+func (sn *SRN) train(input, trainer [][]float64, wts []float64) float64 {
+	// forward propagate, saving context, hidden and output activations
+	sn.Reset()
+	nt := len(input)
+	cs, as, ys := make([][]float64, nt), make([][]float64, nt), make([][]float64, nt)
+	for t, x := range input {
+		cs[t] = make([]float64, len(sn.ctx))
+		for i, c := range sn.ctx {
+			cs[t][i] = c.h
+		}
+		as[t], ys[t] = sn.feed(x)
+		for i, v := range sn.context(as[t], ys[t]) {
+			sn.ctx[i].h = v
+		}
+	}
+
+	// back propagate errors through time
+	m := len(sn.in)
+	dwo, dwh := zeros(len(sn.out), len(sn.hid)), zeros(len(sn.hid), m+len(sn.ctx)) // weight diffs, indexed as node.b
+	dbo, dbh := make([]float64, len(sn.out)), make([]float64, len(sn.hid))         // bias diffs
+	loss, dctx := 0., make([]float64, len(sn.ctx))                                 // dctx: loss gradient w.r.t. the context used at t+1
+	do, dh := make([]float64, len(sn.out)), make([]float64, len(sn.hid))
+	for t := nt - 1; t >= 0; t-- {
+		if sn.trunc > 0 && (nt-1-t)%sn.trunc == 0 {
+			for i := range dctx {
+				dctx[i] = 0.
+			}
+		}
+		for k := range sn.out {
+			e := ys[t][k] - trainer[t][k]
+			if math.IsNaN(e) { // missing observation: not in loss
+				e = 0.
+			}
+			loss += weightAt(wts, t) * e * e
+			e *= weightAt(wts, t)
+			if sn.jordan {
+				e += dctx[k]
+			}
+			do[k] = e * ys[t][k] * (1. - ys[t][k])
+			for j := range sn.hid {
+				dwo[k][j] += do[k] * as[t][j]
+			}
+			dbo[k] += do[k]
+		}
+		for j, n := range sn.hid {
+			da := 0.
+			if !sn.jordan {
+				da = dctx[j]
+			}
+			for k, w := range n.f {
+				da += w.w * do[k]
+			}
+			dh[j] = da * as[t][j] * (1. - as[t][j])
+			for i := 0; i < m; i++ {
+				dwh[j][i] += dh[j] * input[t][i]
+			}
+			for i := range sn.ctx {
+				dwh[j][m+i] += dh[j] * cs[t][i]
+			}
+			dbh[j] += dh[j]
+		}
+		for i, n := range sn.ctx {
+			dctx[i] = 0.
+			for j, w := range n.f {
+				dctx[i] += w.w * dh[j]
+			}
+		}
+	}
+
+	// update weights and biases (steepest descent)
+	for k, n := range sn.out {
+		for j, w := range n.b {
+			w.w -= sn.eta * dwo[k][j]
+		}
+		n.bias -= sn.eta * dbo[k]
+	}
+	for j, n := range sn.hid {
+		for i, w := range n.b {
+			w.w -= sn.eta * dwh[j][i]
+		}
+		n.bias -= sn.eta * dbh[j]
+	}
+	return loss
+}
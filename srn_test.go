@@ -0,0 +1,67 @@
+package goann
+
+import (
+	"math"
+	"testing"
+)
+
+// TestSRNGradient compares a single training step (eta 1) of Elman and Jordan networks against
+// central differences of half the squared error summed over the sequence
+func TestSRNGradient(t *testing.T) {
+	xs, ys := seq(6, 3, 1., 0.), seq(6, 2, .3, 1.)
+	for _, y := range ys {
+		for k := range y {
+			y[k] += .5 // within the sigmoid's range
+		}
+	}
+	for _, sn := range []SRN{NewElman(3, 4, 2, 1.), NewJordan(3, 4, 2, 1.)} {
+		lossf := func() float64 {
+			l := 0.
+			for j, y := range sn.Predict(xs) {
+				for k, v := range y {
+					l += .5 * (v - ys[j][k]) * (v - ys[j][k])
+				}
+			}
+			return l
+		}
+		var ps []*float64 // every weight and bias
+		for _, n := range append(append([]*node{}, sn.hid...), sn.out...) {
+			for _, w := range n.b {
+				ps = append(ps, &w.w)
+			}
+			ps = append(ps, &n.bias)
+		}
+
+		const h = 1e-6
+		num, old := make([]float64, len(ps)), make([]float64, len(ps))
+		for i, p := range ps {
+			old[i] = *p
+			*p = old[i] + h
+			lp := lossf()
+			*p = old[i] - h
+			lm := lossf()
+			*p = old[i]
+			num[i] = (lp - lm) / 2. / h
+		}
+		sn.Train(xs, ys)
+		for i, p := range ps {
+			if g := old[i] - *p; math.Abs(g-num[i]) > 1e-6 {
+				t.Errorf("jordan=%v parameter %d: numerical %g, trained step %g", sn.jordan, i, num[i], g)
+			}
+		}
+	}
+}
+
+func TestSRNTrainWeighted(t *testing.T) {
+	xs, ys := seq(4, 2, 1., 0.), seq(4, 1, .3, 1.)
+	sn := NewElman(2, 3, 1, .1)
+	if _, err := sn.TrainWeighted(xs, ys[:3], nil); err == nil {
+		t.Error("mismatched trainer accepted")
+	}
+	if _, err := sn.TrainWeighted(xs, ys, []float64{1., 1.}); err == nil {
+		t.Error("mismatched weights accepted")
+	}
+	if _, err := sn.TrainWeighted(xs, ys, []float64{1., 0., 1., 1.}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,131 @@
+package goann
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+)
+
+// RecurrentState is a snapshot of the hidden (H) and cell (C) states of every recurrent layer,
+// used to warm a network once and then advance it one timestep at a time (e.g., daily forecasting).
+type RecurrentState struct {
+	H, C [][]float64 // [layer][unit]
+}
+
+func (s RecurrentState) copy() RecurrentState {
+	o := RecurrentState{H: make([][]float64, len(s.H)), C: make([][]float64, len(s.C))}
+	for k := range s.H {
+		o.H[k] = append([]float64(nil), s.H[k]...)
+	}
+	for k := range s.C {
+		o.C[k] = append([]float64(nil), s.C[k]...)
+	}
+	return o
+}
+
+// Save writes the state to a gob file
+func (s RecurrentState) Save(fp string) error {
+	f, err := os.Create(fp)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return gob.NewEncoder(f).Encode(s)
+}
+
+// LoadState reads a state previously written by Save
+func LoadState(fp string) (RecurrentState, error) {
+	var s RecurrentState
+	f, err := os.Open(fp)
+	if err != nil {
+		return s, err
+	}
+	defer f.Close()
+	err = gob.NewDecoder(f).Decode(&s)
+	return s, err
+}
+
+// State returns a snapshot of the current hidden and cell states
+func (ls *LSTMlayers) State() RecurrentState {
+	s := RecurrentState{H: make([][]float64, ls.nl), C: make([][]float64, ls.nl)}
+	for k := 0; k < ls.nl; k++ {
+		s.H[k], s.C[k] = ls.layer[k].h, ls.layer[k].c
+	}
+	return s.copy()
+}
+
+// SetState restores a snapshot taken by State (or read by LoadState)
+func (ls *LSTMlayers) SetState(s RecurrentState) error {
+	if len(s.H) != ls.nl || len(s.C) != ls.nl {
+		return fmt.Errorf("SetState: state has %d layers, network has %d", len(s.H), ls.nl)
+	}
+	for k := 0; k < ls.nl; k++ {
+		if len(s.H[k]) != ls.layer[k].nh || len(s.C[k]) != ls.layer[k].nh {
+			return fmt.Errorf("SetState: layer %d state size does not match %d hidden units", k, ls.layer[k].nh)
+		}
+	}
+	for k := 0; k < ls.nl; k++ {
+		copy(ls.layer[k].h, s.H[k])
+		copy(ls.layer[k].c, s.C[k])
+	}
+	return nil
+}
+
+// State returns a snapshot of the current hidden states (a GRU has no cell state, C is left empty)
+func (gs *GRUlayers) State() RecurrentState {
+	s := RecurrentState{H: make([][]float64, gs.nl)}
+	for k := 0; k < gs.nl; k++ {
+		s.H[k] = gs.layer[k].h
+	}
+	return s.copy()
+}
+
+// SetState restores a snapshot taken by State (or read by LoadState)
+func (gs *GRUlayers) SetState(s RecurrentState) error {
+	if len(s.H) != gs.nl {
+		return fmt.Errorf("SetState: state has %d layers, network has %d", len(s.H), gs.nl)
+	}
+	for k := 0; k < gs.nl; k++ {
+		if len(s.H[k]) != gs.layer[k].nh {
+			return fmt.Errorf("SetState: layer %d state size does not match %d hidden units", k, gs.layer[k].nh)
+		}
+	}
+	for k := 0; k < gs.nl; k++ {
+		copy(gs.layer[k].h, s.H[k])
+	}
+	return nil
+}
+
+// State returns a snapshot of the current hidden and cell states (a single layer)
+func (ea *EALSTM) State() RecurrentState {
+	return RecurrentState{H: [][]float64{ea.h}, C: [][]float64{ea.c}}.copy()
+}
+
+// SetState restores a snapshot taken by State (or read by LoadState)
+func (ea *EALSTM) SetState(s RecurrentState) error {
+	if len(s.H) != 1 || len(s.C) != 1 || len(s.H[0]) != ea.nh || len(s.C[0]) != ea.nh {
+		return fmt.Errorf("SetState: state does not match a single layer of %d hidden units", ea.nh)
+	}
+	copy(ea.h, s.H[0])
+	copy(ea.c, s.C[0])
+	return nil
+}
+
+// State returns a snapshot of the current prediction state (a single layer)
+func (lw *LSTMnetwork) State() RecurrentState {
+	s := RecurrentState{H: [][]float64{lw.Hidden()}, C: [][]float64{zeros(1, lw.param.mem_cell_ct)[0]}}
+	if lw.s != nil {
+		copy(s.C[0], lw.s)
+	}
+	return s
+}
+
+// SetState restores a snapshot taken by State (or read by LoadState)
+func (lw *LSTMnetwork) SetState(s RecurrentState) error {
+	if len(s.H) != 1 || len(s.C) != 1 || len(s.H[0]) != lw.param.mem_cell_ct || len(s.C[0]) != lw.param.mem_cell_ct {
+		return fmt.Errorf("SetState: state does not match a single layer of %d memory cells", lw.param.mem_cell_ct)
+	}
+	s = s.copy()
+	lw.h, lw.s = s.H[0], s.C[0]
+	return nil
+}
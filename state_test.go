@@ -0,0 +1,60 @@
+package goann
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+type stateful interface {
+	Recurrent
+	State() RecurrentState
+	SetState(RecurrentState) error
+}
+
+// TestStateRoundTrip warms every network over the first half of a sequence and saves its state.
+// Restoring the state read back must reproduce the predictions over the second half.
+func TestStateRoundTrip(t *testing.T) {
+	ls := NewLSTM(2, []int{3, 2}, 1, Linear, .1)
+	gs := NewGRU(2, []int{3}, 1, Linear, .1)
+	ea := NewEALSTM(2, 1, 3, 1, Linear, .1)
+	if err := ea.SetStatic([]float64{.5}); err != nil {
+		t.Fatal(err)
+	}
+	lw := NewLSTMnetwork(NewLSTMparam(3, 2), 1, Linear)
+
+	xs := seq(10, 2, 1., 0.)
+	fp := filepath.Join(t.TempDir(), "state.gob")
+	for _, c := range []struct {
+		name string
+		r    stateful
+	}{{"LSTMlayers", &ls}, {"GRUlayers", &gs}, {"EALSTM", &ea}, {"LSTMnetwork", &lw}} {
+		c.r.Reset()
+		for _, x := range xs[:5] {
+			c.r.Step(x)
+		}
+		if err := c.r.State().Save(fp); err != nil {
+			t.Fatal(err)
+		}
+		want := make([][]float64, 5)
+		for i, x := range xs[5:] {
+			want[i] = c.r.Step(x)
+		}
+
+		c.r.Reset()
+		s, err := LoadState(fp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.r.SetState(s); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		for i, x := range xs[5:] {
+			if y := c.r.Step(x); y[0] != want[i][0] {
+				t.Errorf("%s: step %d predicts %g from the restored state, %g from the warmed state", c.name, i, y[0], want[i][0])
+			}
+		}
+		if err := c.r.SetState(RecurrentState{}); err == nil {
+			t.Errorf("%s: empty state accepted", c.name)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package goann
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// FlowPercentileWeights returns a loss weight for every observation in q: observations exceeding
+// the flow percentile p (e.g., .9 for the highest 10% of flows) are given weight w, others 1.
+// Missing (NaN) observations are given weight 1 (they are excluded from the loss regardless).
+func FlowPercentileWeights(q []float64, p, w float64) []float64 {
+	s := make([]float64, 0, len(q))
+	for _, v := range q {
+		if !math.IsNaN(v) {
+			s = append(s, v)
+		}
+	}
+	o := make([]float64, len(q))
+	for i := range o {
+		o[i] = 1.
+	}
+	if len(s) == 0 {
+		return o
+	}
+	sort.Float64s(s)
+	thr := empiricalQuantile(s, p)
+	for i, v := range q {
+		if v > thr {
+			o[i] = w
+		}
+	}
+	return o
+}
+
+// RecencyWeights returns a loss weight for every timestamp, decaying exponentially with age from
+// the most recent timestamp (weight 1), halving every halfLife years. Useful for non-stationary basins.
+func RecencyWeights(ts []time.Time, halfLife float64) []float64 {
+	o := make([]float64, len(ts))
+	if len(ts) == 0 {
+		return o
+	}
+	last := ts[0]
+	for _, t := range ts {
+		if t.After(last) {
+			last = t
+		}
+	}
+	for i, t := range ts {
+		age := last.Sub(t).Hours() / 24. / 365.25 // years
+		o[i] = math.Pow(.5, age/halfLife)
+	}
+	return o
+}
+
+// MultiplyWeights returns the element-wise product of weight vectors of equal length (nil: 1),
+// e.g., to combine flow-percentile, recency and FlagPolicy weights
+func MultiplyWeights(ws ...[]float64) []float64 {
+	var o []float64
+	for _, w := range ws {
+		if w == nil {
+			continue
+		}
+		if o == nil {
+			o = append([]float64(nil), w...)
+			continue
+		}
+		for i := range o {
+			o[i] *= w[i]
+		}
+	}
+	return o
+}
@@ -0,0 +1,96 @@
+package goann
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Lookback configures sequence-to-one training (Kratzert et.al., 2019): random windows of
+// Lookback timesteps are sampled from a long record, where only the final Targets timesteps
+// contribute to the loss; the preceding Lookback-Targets timesteps serve as warm-up (spin-up).
+type Lookback struct {
+	Lookback  int  // window length (e.g., 365 days)
+	Targets   int  // number of final timesteps in the loss (default 1: sequence-to-one)
+	BatchSize int  // windows per mini-batch (default 1)
+	Loss      Loss // loss layer, e.g., a Distribution for probabilistic heads (default: squared error back-propagating e = pred-label, half the gradient of MSE{})
+}
+
+func (lb Lookback) check(n int) (Lookback, error) {
+	if lb.Targets < 1 {
+		lb.Targets = 1
+	}
+	if lb.BatchSize < 1 {
+		lb.BatchSize = 1
+	}
+	if lb.Loss == nil {
+		lb.Loss = halfMSE{} // the step size of lookback training prior to pluggable losses
+	}
+	if lb.Lookback < lb.Targets {
+		return lb, fmt.Errorf("lookback (%d) must be at least the number of targets (%d)", lb.Lookback, lb.Targets)
+	}
+	if lb.Lookback > n {
+		return lb, fmt.Errorf("lookback (%d) exceeds record length (%d)", lb.Lookback, n)
+	}
+	return lb, nil
+}
+
+// sample returns the starting index of a random window
+func (lb Lookback) sample(n int) int {
+	return rand.Intn(n - lb.Lookback + 1)
+}
+
+// TrainLookback trains nbatch mini-batches of randomly sampled lookback windows taken from input
+// (sequence of forcing vectors) and trainer (sequence of target vectors). Diffs are averaged over
+// each mini-batch before being applied. Returns the mean loss of the final batch.
+func (ls *LSTMlayers) TrainLookback(input, trainer [][]float64, lb Lookback, nbatch int) (float64, error) {
+	return TrainLookback(ls, input, trainer, lb, nbatch, ls.eta)
+}
+
+// TrainLookback trains any recurrent network on random lookback windows (see LSTMlayers.TrainLookback), learning rate lr
+func TrainLookback(r Recurrent, input, trainer [][]float64, lb Lookback, nbatch int, lr float64) (float64, error) {
+	return TrainLookbackWeighted(r, input, trainer, nil, lb, nbatch, lr)
+}
+
+// TrainLookbackWeighted as TrainLookback, where the loss of every timestep is scaled by its weight (nil: 1)
+func TrainLookbackWeighted(r Recurrent, input, trainer [][]float64, wts []float64, lb Lookback, nbatch int, lr float64) (float64, error) {
+	if len(input) != len(trainer) {
+		return 0., fmt.Errorf("TrainLookback: input (%d) and trainer (%d) lengths differ", len(input), len(trainer))
+	}
+	if wts != nil && len(wts) != len(input) {
+		return 0., fmt.Errorf("TrainLookback: input (%d) and weight (%d) lengths differ", len(input), len(wts))
+	}
+	lb, err := lb.check(len(input))
+	if err != nil {
+		return 0., fmt.Errorf("TrainLookback: %v", err)
+	}
+
+	scl, loss := 1./float64(lb.BatchSize*lb.Targets), 0.
+	for b := 0; b < nbatch; b++ {
+		loss = 0.
+		for n := 0; n < lb.BatchSize; n++ {
+			loss += lb.window(r, input, trainer, wts, lb.sample(len(input)), scl)
+		}
+		ApplyDiff(r.Params(), lr)
+	}
+	return loss * scl, nil
+}
+
+// window forward propagates the lookback window starting at s (from rest), then back-propagates
+// the loss gradients of its final Targets timesteps, scaled by scl and by their weight (nil: 1).
+// Returns the summed (weighted) loss.
+func (lb Lookback) window(r Recurrent, input, trainer [][]float64, wts []float64, s int, scl float64) float64 {
+	r.Reset()
+	ypred := r.Forward(input[s : s+lb.Lookback])
+
+	loss, dy := 0., make([][]float64, lb.Lookback) // spin-up excluded from loss
+	for j := lb.Lookback - lb.Targets; j < lb.Lookback; j++ {
+		w := weightAt(wts, s+j)
+		loss += w * lb.Loss.Loss(ypred[j], trainer[s+j])
+		dy[j] = lb.Loss.Diff(ypred[j], trainer[s+j])
+		for k := range dy[j] {
+			dy[j][k] *= w * scl
+		}
+	}
+	r.Backward(dy)
+	return loss
+}